@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rinatkh/db_forum/internal/auth"
+	"github.com/rinatkh/db_forum/internal/model/core"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	"github.com/spf13/pflag"
+)
+
+const (
+	userUsage  = "user ban|unban|admin|unadmin|token NICKNAME [--name NAME]"
+	forumUsage = "forum delete SLUG"
+)
+
+var reconcileCommand = &command{
+	usage:   "reconcile",
+	summary: "Recompute forum counters, thread votes and forum participants",
+	run: func(a *app, _ *pflag.FlagSet) error {
+		if err := a.repository.ServiceRepository.Reconcile(context.Background()); err != nil {
+			return err
+		}
+		a.log.Info("reconcile finished")
+		return nil
+	},
+	longRunning: true,
+}
+
+var exportCommand = &command{
+	usage:   "export [--output FILE]",
+	summary: "Dump all forum data as JSON lines",
+	flags: func(flags *pflag.FlagSet) {
+		flags.StringP("output", "o", "-", "output file, - for stdout")
+	},
+	run:         runExport,
+	longRunning: true,
+}
+
+var importCommand = &command{
+	usage:   "import [--input FILE]",
+	summary: "Load JSON lines produced by export in one transaction",
+	flags: func(flags *pflag.FlagSet) {
+		flags.StringP("input", "i", "-", "input file, - for stdin")
+	},
+	run:         runImport,
+	longRunning: true,
+}
+
+var userCommand = &command{
+	usage:   userUsage,
+	summary: "Ban or unban a user, grant or revoke site admin, or issue an API token",
+	flags: func(flags *pflag.FlagSet) {
+		flags.String("name", "", "label of the issued API token")
+	},
+	run: runUser,
+}
+
+var forumCommand = &command{
+	usage:   forumUsage,
+	summary: "Delete a forum with all its threads, posts and votes",
+	run:     runForum,
+}
+
+func runExport(a *app, flags *pflag.FlagSet) error {
+	path, _ := flags.GetString("output")
+
+	out := os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+	encoder := json.NewEncoder(w)
+	var exported int64
+	err := a.repository.ServiceRepository.Export(context.Background(), func(record *core.DumpRecord) error {
+		exported++
+		return encoder.Encode(record)
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	a.log.Infof("exported %d record(s)", exported)
+	return nil
+}
+
+func runImport(a *app, flags *pflag.FlagSet) error {
+	path, _ := flags.GetString("input")
+
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	decoder := json.NewDecoder(bufio.NewReader(in))
+	imported, err := a.repository.ServiceRepository.Import(context.Background(), func() (*core.DumpRecord, error) {
+		record := &core.DumpRecord{}
+		if err := decoder.Decode(record); err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("decode record: %w", err)
+		}
+		return record, nil
+	})
+	if err != nil {
+		return err
+	}
+	a.log.Infof("imported %d record(s)", imported)
+	return nil
+}
+
+func runUser(a *app, flags *pflag.FlagSet) error {
+	args := flags.Args()
+	if len(args) != 2 {
+		return fmt.Errorf("usage: %s", userUsage)
+	}
+
+	if args[0] == "token" {
+		name, _ := flags.GetString("name")
+		token, err := a.registry.AuthService.CreateAPIToken(auth.AsSystem(context.Background()), args[1], name)
+		if err != nil {
+			return err
+		}
+		fmt.Println(token)
+		return nil
+	}
+
+	var err error
+	switch args[0] {
+	case "ban", "unban":
+		_, err = a.registry.UserService.BanUser(auth.AsSystem(context.Background()), &dto.BanUserRequest{Nickname: args[1], Banned: args[0] == "ban"})
+	case "admin", "unadmin":
+		_, err = a.registry.UserService.SetUserAdmin(auth.AsSystem(context.Background()), &dto.SetUserAdminRequest{Nickname: args[1], Admin: args[0] == "admin"})
+	default:
+		return fmt.Errorf("usage: %s", userUsage)
+	}
+	if err != nil {
+		return err
+	}
+	a.log.Infof("user %s: %s", args[1], args[0])
+	return nil
+}
+
+func runForum(a *app, flags *pflag.FlagSet) error {
+	args := flags.Args()
+	if len(args) != 2 || args[0] != "delete" {
+		return fmt.Errorf("usage: %s", forumUsage)
+	}
+
+	forum, err := a.registry.ForumService.DeleteForum(auth.AsSystem(context.Background()), &dto.DeleteForumRequest{Slug: args[1]})
+	if err != nil {
+		return err
+	}
+	a.log.Infof("deleted forum %s with %d thread(s) and %d post(s)", forum.Slug, forum.Threads, forum.Posts)
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rinatkh/db_forum/internal/migrate"
+	"github.com/spf13/pflag"
+)
+
+const migrateUsage = "migrate up | down [N] | status"
+
+var migrateCommand = &command{
+	usage:       migrateUsage,
+	summary:     "Apply, revert or list schema migrations",
+	run:         runMigrate,
+	longRunning: true,
+}
+
+func runMigrate(a *app, flags *pflag.FlagSet) error {
+	args := flags.Args()
+	if len(args) == 0 {
+		return fmt.Errorf("usage: %s", migrateUsage)
+	}
+
+	migrator, err := migrate.NewMigrator(a.log, a.dbPool)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		a.log.Infof("applied %d migration(s)", applied)
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+				return fmt.Errorf("invalid number of migrations: %s", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, n)
+		if err != nil {
+			return err
+		}
+		a.log.Infof("reverted %d migration(s)", reverted)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied at " + status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command: %s", args[0])
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	"github.com/spf13/pflag"
+)
+
+var seedCommand = &command{
+	usage:   "seed",
+	summary: "Fill the database with generated users, forums, threads, posts and votes",
+	flags: func(flags *pflag.FlagSet) {
+		flags.Int("users", 100, "number of users to create")
+		flags.Int("forums", 10, "number of forums to create")
+		flags.Int("threads", 100, "number of threads to create")
+		flags.Int("posts", 1000, "number of posts to create")
+		flags.Int("votes", 1000, "number of votes to cast")
+		flags.Int("batch", 100, "posts per create request")
+		flags.Int64("seed", time.Now().UnixNano(), "random seed")
+	},
+	run:         runSeed,
+	longRunning: true,
+}
+
+type seeder struct {
+	a   *app
+	rnd *rand.Rand
+	// prefix keeps generated nicknames and slugs unique across several runs.
+	prefix  string
+	users   []string
+	forums  []string
+	threads []string
+}
+
+func runSeed(a *app, flags *pflag.FlagSet) error {
+	users, _ := flags.GetInt("users")
+	forums, _ := flags.GetInt("forums")
+	threads, _ := flags.GetInt("threads")
+	posts, _ := flags.GetInt("posts")
+	votes, _ := flags.GetInt("votes")
+	batch, _ := flags.GetInt("batch")
+	seed, _ := flags.GetInt64("seed")
+
+	if users < 1 || (forums < 1 && threads > 0) || (threads < 1 && (posts > 0 || votes > 0)) || batch < 1 {
+		return fmt.Errorf("seed needs at least one user, forum and thread for dependent entities")
+	}
+
+	s := &seeder{a: a, rnd: rand.New(rand.NewSource(seed)), prefix: fmt.Sprintf("s%x", seed&0xffffff)}
+	ctx := context.Background()
+	steps := []struct {
+		name string
+		n    int
+		f    func(ctx context.Context, n int) error
+	}{
+		{"users", users, s.createUsers},
+		{"forums", forums, s.createForums},
+		{"threads", threads, s.createThreads},
+		{"posts", posts, func(ctx context.Context, n int) error { return s.createPosts(ctx, n, batch) }},
+		{"votes", votes, s.castVotes},
+	}
+	for _, step := range steps {
+		start := time.Now()
+		if err := step.f(ctx, step.n); err != nil {
+			return fmt.Errorf("seed %s: %w", step.name, err)
+		}
+		a.log.Infof("seeded %d %s in %s", step.n, step.name, time.Since(start))
+	}
+	return nil
+}
+
+func (s *seeder) createUsers(ctx context.Context, n int) error {
+	for i := 0; i < n; i++ {
+		nickname := fmt.Sprintf("%s.user%d", s.prefix, i)
+		_, err := s.a.registry.UserService.CreateUser(ctx, &dto.CreateUserRequest{
+			Nickname: nickname,
+			Fullname: fmt.Sprintf("Seed User %d", i),
+			About:    s.text(8),
+			Email:    nickname + "@seed.example",
+		})
+		if err != nil {
+			return err
+		}
+		s.users = append(s.users, nickname)
+	}
+	return nil
+}
+
+func (s *seeder) createForums(ctx context.Context, n int) error {
+	for i := 0; i < n; i++ {
+		slug := fmt.Sprintf("%s-forum-%d", s.prefix, i)
+		_, err := s.a.registry.ForumService.CreateForum(ctx, &dto.CreateForumRequest{
+			Title: s.text(3),
+			User:  s.user(),
+			Slug:  slug,
+		})
+		if err != nil {
+			return err
+		}
+		s.forums = append(s.forums, slug)
+	}
+	return nil
+}
+
+func (s *seeder) createThreads(ctx context.Context, n int) error {
+	for i := 0; i < n; i++ {
+		slug := fmt.Sprintf("%s-thread-%d", s.prefix, i)
+		_, err := s.a.registry.ThreadService.CreateThread(ctx, &dto.CreateThreadRequest{
+			Author:  s.user(),
+			Forum:   s.forums[s.rnd.Intn(len(s.forums))],
+			Slug:    slug,
+			Title:   s.text(4),
+			Message: s.text(30),
+			Created: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		s.threads = append(s.threads, slug)
+	}
+	return nil
+}
+
+// createPosts sends top-level posts in batches, as the course benchmark does;
+// replies are left out since a parent must live in the same thread.
+func (s *seeder) createPosts(ctx context.Context, n int, batch int) error {
+	for created := 0; created < n; created += batch {
+		size := batch
+		if n-created < size {
+			size = n - created
+		}
+
+		posts := make([]*dto.Post, 0, size)
+		for i := 0; i < size; i++ {
+			posts = append(posts, &dto.Post{Author: s.user(), Message: s.text(20)})
+		}
+		_, err := s.a.registry.PostsService.CreatePosts(ctx, s.thread(), posts)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *seeder) castVotes(ctx context.Context, n int) error {
+	for i := 0; i < n; i++ {
+		voice := int64(1)
+		if s.rnd.Intn(2) == 0 {
+			voice = -1
+		}
+		_, err := s.a.registry.ThreadService.CountVote(ctx, s.thread(), &dto.EditVoteRequest{Nickname: s.user(), Voice: voice})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *seeder) user() string {
+	return s.users[s.rnd.Intn(len(s.users))]
+}
+
+func (s *seeder) thread() string {
+	return s.threads[s.rnd.Intn(len(s.threads))]
+}
+
+var seedWords = []string{
+	"forum", "thread", "post", "reply", "query", "index", "vote", "user", "table", "tree",
+	"path", "slug", "trigger", "cursor", "page", "limit", "since", "order", "batch", "count",
+}
+
+func (s *seeder) text(words int) string {
+	buf := make([]byte, 0, words*8)
+	for i := 0; i < words; i++ {
+		if i > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, seedWords[s.rnd.Intn(len(seedWords))]...)
+	}
+	return string(buf)
+}
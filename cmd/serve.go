@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rinatkh/db_forum/internal/api"
+	"github.com/spf13/pflag"
+)
+
+var serveCommand = &command{
+	usage:   "serve",
+	summary: "Start the HTTP and gRPC API servers (default command)",
+	run:     runServe,
+}
+
+func runServe(a *app, _ *pflag.FlagSet) error {
+	// -------------------- Set up service -------------------- //
+
+	svc, err := api.NewAPIService(a.log, a.dbPool, a.cfg)
+	if err != nil {
+		return err
+	}
+
+	go svc.Serve()
+	// -------------------- Listen for INT/TERM signal -------------------- //
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	sig := <-quit
+	a.log.Infof("received %s, shutting down", sig)
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.Server.DrainDelay+a.cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	return svc.Shutdown(ctx)
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/logging"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	"github.com/rinatkh/db_forum/internal/service"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type AuthController struct {
+	log      *logrus.Entry
+	registry *service.Registry
+}
+
+func (c *AuthController) Login(ctx echo.Context) error {
+	request := new(dto.LoginRequest)
+	if err := ctx.Bind(request); err != nil {
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
+		return err
+	}
+
+	res, err := c.registry.AuthService.Login(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (c *AuthController) SetPassword(ctx echo.Context) error {
+	request := new(dto.SetPasswordRequest)
+	if err := ctx.Bind(request); err != nil {
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
+		return err
+	}
+
+	res, err := c.registry.AuthService.SetPassword(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func NewAuthController(log *logrus.Entry, registry *service.Registry) *AuthController {
+	return &AuthController{log: log, registry: registry}
+}
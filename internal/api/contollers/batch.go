@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/db"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/logging"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	"github.com/sirupsen/logrus"
+)
+
+// errBatchFailed rolls back an atomic batch after a request failed.
+var errBatchFailed = errors.New("batch request failed")
+
+// forwardedHeaders are copied from the batch to its requests, so that they
+// are authenticated like the batch. Client IP headers are not: the requests
+// come from the client IP of the batch, which they can't change.
+var forwardedHeaders = []string{echo.HeaderAuthorization}
+
+type BatchController struct {
+	log         *logrus.Entry
+	transactor  db.Transactor
+	maxRequests int
+	// excluded names the routes that can't be batched, with the reason.
+	excluded map[string]string
+	// trustedHeader is forwarded as well when it is configured.
+	trustedHeader string
+}
+
+// Batch runs the requests in order through the API routes and answers with
+// their statuses and bodies. Requests of an atomic batch share a database
+// transaction; after the first failure it is rolled back and the remaining
+// requests are not run.
+func (c *BatchController) Batch(ctx echo.Context) error {
+	request := new(dto.BatchRequest)
+	if err := ctx.Bind(request); err != nil {
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
+		return err
+	}
+	if err := c.check(ctx, request); err != nil {
+		return err
+	}
+
+	response := &dto.BatchResponse{Responses: make([]*dto.BatchItemResponse, 0, len(request.Requests))}
+	if !request.Atomic {
+		for _, item := range request.Requests {
+			response.Responses = append(response.Responses, c.serve(ctx, ctx.Request().Context(), item))
+		}
+		return ctx.JSON(http.StatusOK, response)
+	}
+
+	err := c.transactor.InTx(ctx.Request().Context(), func(txCtx context.Context) error {
+		for _, item := range request.Requests {
+			res := c.serve(ctx, txCtx, item)
+			response.Responses = append(response.Responses, res)
+			if res.Status >= http.StatusBadRequest {
+				return errBatchFailed
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBatchFailed) {
+		return err
+	}
+	committed := err == nil
+	response.Committed = &committed
+	for n := len(response.Responses); n < len(request.Requests); n++ {
+		body, err := marshal(ctx, dto.ErrorResponse{Message: fmt.Sprintf("Not run: request %d failed", len(response.Responses)-1)})
+		if err != nil {
+			return err
+		}
+		response.Responses = append(response.Responses, &dto.BatchItemResponse{Status: http.StatusFailedDependency, Body: body})
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// check rejects batches that are too long or name routes that can't be
+// batched, before any of their requests runs.
+func (c *BatchController) check(ctx echo.Context, request *dto.BatchRequest) error {
+	if c.maxRequests > 0 && len(request.Requests) > c.maxRequests {
+		return domain.InvalidFields(domain.FieldError{Field: "requests", Message: fmt.Sprintf("must contain at most %d requests", c.maxRequests)})
+	}
+	var fields []domain.FieldError
+	for n, item := range request.Requests {
+		route := ctx.Echo().NewContext(nil, nil)
+		ctx.Echo().Router().Find(item.Method, strings.SplitN(item.Path, "?", 2)[0], route)
+		if reason, ok := c.excluded[route.Path()]; ok && (reason != "" || request.Atomic) {
+			if reason == "" {
+				reason = "can't run in an atomic batch"
+			}
+			fields = append(fields, domain.FieldError{Field: fmt.Sprintf("requests[%d].path", n), Message: reason})
+		}
+	}
+	if len(fields) > 0 {
+		return domain.InvalidFields(fields...)
+	}
+	return nil
+}
+
+// serve runs one request of the batch with reqCtx as its context.
+func (c *BatchController) serve(ctx echo.Context, reqCtx context.Context, item *dto.BatchItem) *dto.BatchItemResponse {
+	outer := ctx.Request()
+	req, err := http.NewRequestWithContext(reqCtx, item.Method, item.Path, bytes.NewReader(item.Body))
+	if err != nil {
+		body, _ := marshal(ctx, dto.ErrorResponse{Message: "Malformed request: " + err.Error()})
+		return &dto.BatchItemResponse{Status: http.StatusBadRequest, Body: body}
+	}
+	// The client IP resolved for the batch, possibly through a trusted
+	// proxy, is the peer of its requests, so they share its rate limits.
+	req.RemoteAddr = net.JoinHostPort(ctx.RealIP(), "0")
+	if len(item.Body) > 0 {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	for _, header := range append(forwardedHeaders, c.trustedHeader) {
+		if value := outer.Header.Get(header); header != "" && value != "" {
+			req.Header.Set(header, value)
+		}
+	}
+	if requestID := ctx.Response().Header().Get(echo.HeaderXRequestID); requestID != "" {
+		req.Header.Set(echo.HeaderXRequestID, requestID)
+	}
+
+	recorder := &batchRecorder{header: make(http.Header)}
+	ctx.Echo().ServeHTTP(recorder, req)
+
+	res := &dto.BatchItemResponse{Status: recorder.status}
+	if body := recorder.body.Bytes(); json.Valid(body) {
+		res.Body = body
+	}
+	return res
+}
+
+// batchRecorder keeps the response to one request of a batch.
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *batchRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *batchRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *batchRecorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(b)
+}
+
+func (r *batchRecorder) Flush() {}
+
+// NewBatchController serves batches of at most maxRequests requests, zero
+// meaning no limit. The excluded routes are rejected with the given reason,
+// or only in atomic batches when the reason is empty.
+func NewBatchController(log *logrus.Entry, transactor db.Transactor, maxRequests int, excluded map[string]string, trustedHeader string) *BatchController {
+	return &BatchController{log: log, transactor: transactor, maxRequests: maxRequests, excluded: excluded, trustedHeader: trustedHeader}
+}
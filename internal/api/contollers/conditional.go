@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/codec"
+)
+
+const (
+	headerETag        = "ETag"
+	headerIfNoneMatch = "If-None-Match"
+)
+
+// writeConditional answers a GET for a single resource. The body gets a
+// strong ETag derived from its encoding and, if modified is known, a
+// Last-Modified header; a request whose validators still match is answered
+// with 304 and no body. If-None-Match takes precedence over
+// If-Modified-Since, as in RFC 7232.
+func writeConditional(ctx echo.Context, value interface{}, modified time.Time) error {
+	body, err := marshal(ctx, value)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:18]) + `"`
+
+	header := ctx.Response().Header()
+	header.Set(headerETag, etag)
+	header.Set(echo.HeaderCacheControl, "no-cache")
+	if !modified.IsZero() {
+		header.Set(echo.HeaderLastModified, modified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(ctx.Request(), etag, modified) {
+		return ctx.NoContent(http.StatusNotModified)
+	}
+	return ctx.JSONBlob(http.StatusOK, body)
+}
+
+// marshal encodes value with the codec behind the server's JSON serializer,
+// if it exposes one.
+func marshal(ctx echo.Context, value interface{}) ([]byte, error) {
+	if c, ok := ctx.Echo().JSONSerializer.(codec.Codec); ok {
+		return c.Marshal(value)
+	}
+	return json.Marshal(value)
+}
+
+func notModified(req *http.Request, etag string, modified time.Time) bool {
+	if match := req.Header.Get(headerIfNoneMatch); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if since := req.Header.Get(echo.HeaderIfModifiedSince); since != "" && !modified.IsZero() {
+		t, err := http.ParseTime(since)
+		return err == nil && !modified.Truncate(time.Second).After(t)
+	}
+	return false
+}
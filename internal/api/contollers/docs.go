@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// docsPage renders the spec served next to it with Swagger UI.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>forum API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@4/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@4/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "swagger.yaml", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+type DocsController struct {
+	log  *logrus.Entry
+	spec []byte
+}
+
+func (c *DocsController) Spec(ctx echo.Context) error {
+	return ctx.Blob(http.StatusOK, "application/yaml", c.spec)
+}
+
+func (c *DocsController) UI(ctx echo.Context) error {
+	return ctx.HTML(http.StatusOK, docsPage)
+}
+
+func NewDocsController(log *logrus.Entry, spec []byte) *DocsController {
+	return &DocsController{log: log, spec: spec}
+}
@@ -1,11 +1,13 @@
 package controllers
 
 import (
-	"context"
 	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/logging"
 	"github.com/rinatkh/db_forum/internal/model/dto"
 	"github.com/rinatkh/db_forum/internal/service"
 	"github.com/sirupsen/logrus"
+	"net/http"
+	"time"
 )
 
 type ForumController struct {
@@ -17,69 +19,125 @@ func (c *ForumController) CreateForum(ctx echo.Context) error {
 	request := new(dto.CreateForumRequest)
 
 	if err := ctx.Bind(request); err != nil {
-		c.log.Errorf("Bind error: %s", err)
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
 		return err
 	}
 
-	response, err := c.registry.ForumService.CreateForum(context.Background(), request)
+	res, err := c.registry.ForumService.CreateForum(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
-	return ctx.JSON(response.Code, response.Data)
+	return ctx.JSON(http.StatusCreated, res)
 }
 
 func (c *ForumController) GetForumUsers(ctx echo.Context) error {
 	request := new(dto.GetForumUsersRequest)
 
 	if err := ctx.Bind(request); err != nil {
-		c.log.Errorf("Bind error: %s", err)
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
 		return err
 	}
-	request.Slug = ctx.Param("slug")
-	if request.Limit < -1 || request.Limit == 0 {
+	if request.Limit == 0 {
 		request.Limit = 100
 	}
+	if cursorMode(ctx) {
+		page, err := c.registry.ForumService.GetForumUsersPage(ctx.Request().Context(), request)
+		if err != nil {
+			return err
+		}
+		return writeShapedPage(ctx, c.registry, page, request.Fields, "", nil)
+	}
 
-	response, err := c.registry.ForumService.GetForumUsers(context.Background(), request)
+	res, err := c.registry.ForumService.GetForumUsers(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
-	return ctx.JSON(response.Code, response.Data)
+	return writeList(ctx, c.registry, res, request.Fields, "", nil)
 }
 
 func (c *ForumController) GetForum(ctx echo.Context) error {
 	request := new(dto.GetForumRequest)
 
 	if err := ctx.Bind(request); err != nil {
-		c.log.Errorf("Bind error: %s", err)
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
 		return err
 	}
-	request.Slug = ctx.Param("slug")
 
-	response, err := c.registry.ForumService.GetForum(context.Background(), request)
+	res, err := c.registry.ForumService.GetForum(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
-	return ctx.JSON(response.Code, response.Data)
+	return writeConditional(ctx, res, time.Time{})
 }
 
 func (c *ForumController) GetForumThreads(ctx echo.Context) error {
 	request := new(dto.GetForumThreadsRequest)
 
 	if err := ctx.Bind(request); err != nil {
-		c.log.Errorf("Bind error: %s", err)
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
 		return err
 	}
-	request.Slug = ctx.Param("slug")
-	if request.Limit < -1 || request.Limit == 0 {
+	if request.Limit == 0 {
 		request.Limit = 100
 	}
+	if cursorMode(ctx) {
+		page, err := c.registry.ForumService.GetForumThreadsPage(ctx.Request().Context(), request)
+		if err != nil {
+			return err
+		}
+		return writeShapedPage(ctx, c.registry, page, request.Fields, request.Embed, threadRefs)
+	}
+
+	res, err := c.registry.ForumService.GetForumThreads(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return writeList(ctx, c.registry, res, request.Fields, request.Embed, threadRefs)
+}
+
+func (c *ForumController) GetModerators(ctx echo.Context) error {
+	request := new(dto.GetModeratorsRequest)
+
+	if err := ctx.Bind(request); err != nil {
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
+		return err
+	}
+
+	res, err := c.registry.ForumService.GetModerators(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (c *ForumController) AddModerator(ctx echo.Context) error {
+	request := new(dto.ModeratorRequest)
+
+	if err := ctx.Bind(request); err != nil {
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
+		return err
+	}
+
+	res, err := c.registry.ForumService.AddModerator(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (c *ForumController) RemoveModerator(ctx echo.Context) error {
+	request := new(dto.ModeratorRequest)
+
+	if err := ctx.Bind(request); err != nil {
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
+		return err
+	}
 
-	response, err := c.registry.ForumService.GetForumThreads(context.Background(), request)
+	res, err := c.registry.ForumService.RemoveModerator(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
-	return ctx.JSON(response.Code, response.Data)
+	return ctx.JSON(http.StatusOK, res)
 }
 
 func NewForumController(log *logrus.Entry, registry *service.Registry) *ForumController {
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/graphql"
+	"github.com/rinatkh/db_forum/internal/logging"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type GraphQLController struct {
+	log      *logrus.Entry
+	executor graphql.Executor
+}
+
+// Query answers with 200 whenever the request could be read; failures of
+// the query itself are reported in the errors of the response.
+func (c *GraphQLController) Query(ctx echo.Context) error {
+	request := new(dto.GraphQLRequest)
+	if err := ctx.Bind(request); err != nil {
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
+		return err
+	}
+
+	res := c.executor.Exec(ctx.Request().Context(), request)
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func NewGraphQLController(log *logrus.Entry, executor graphql.Executor) *GraphQLController {
+	return &GraphQLController{log: log, executor: executor}
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/migrate"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const readinessCheckTimeout = 2 * time.Second
+
+type HealthController struct {
+	log      *logrus.Entry
+	dbConn   *pgxpool.Pool
+	migrator *migrate.Migrator
+	draining int32
+}
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Liveness only reports that the process is able to serve HTTP.
+func (c *HealthController) Liveness(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// Readiness reports whether the instance should receive traffic: the pool can
+// reach Postgres, the schema is up to date and shutdown has not started.
+func (c *HealthController) Readiness(ctx echo.Context) error {
+	checkCtx, cancel := context.WithTimeout(ctx.Request().Context(), readinessCheckTimeout)
+	defer cancel()
+
+	checks := map[string]string{"database": "ok", "migrations": "ok", "draining": "ok"}
+	ready := true
+
+	if atomic.LoadInt32(&c.draining) == 1 {
+		checks["draining"] = "shutting down"
+		ready = false
+	}
+
+	if err := c.dbConn.Ping(checkCtx); err != nil {
+		checks["database"] = err.Error()
+		ready = false
+	}
+
+	if version, err := c.migrator.Version(checkCtx); err != nil {
+		checks["migrations"] = err.Error()
+		ready = false
+	} else if version != c.migrator.Latest() {
+		checks["migrations"] = fmt.Sprintf("schema version %d, expected %d", version, c.migrator.Latest())
+		ready = false
+	}
+
+	if !ready {
+		c.log.Warnf("readiness check failed: %v", checks)
+		return ctx.JSON(http.StatusServiceUnavailable, healthResponse{Status: "unavailable", Checks: checks})
+	}
+	return ctx.JSON(http.StatusOK, healthResponse{Status: "ok", Checks: checks})
+}
+
+// SetDraining makes every following readiness check fail.
+func (c *HealthController) SetDraining() {
+	atomic.StoreInt32(&c.draining, 1)
+}
+
+func NewHealthController(log *logrus.Entry, dbConn *pgxpool.Pool, migrator *migrate.Migrator) *HealthController {
+	return &HealthController{log: log, dbConn: dbConn, migrator: migrator}
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+)
+
+// cursorMode reports whether the client opted into cursor pagination by
+// passing a cursor parameter, left empty for the first page.
+func cursorMode(ctx echo.Context) bool {
+	return ctx.QueryParams().Has("cursor")
+}
+
+// writePage answers with the page and advertises its neighbours as Link
+// headers.
+func writePage[T any](ctx echo.Context, page *dto.Page[T]) error {
+	writeLinks(ctx, page.NextCursor, page.PrevCursor)
+	return ctx.JSON(http.StatusOK, page)
+}
+
+// writeLinks advertises the neighbours of a page as RFC 8288 Link headers:
+// the request URL with the cursor replaced. since and desc are dropped as the
+// cursor already fixes the position and the order.
+func writeLinks(ctx echo.Context, next, prev string) {
+	links := []struct{ cursor, rel string }{{next, "next"}, {prev, "prev"}}
+	for _, link := range links {
+		if link.cursor == "" {
+			continue
+		}
+		target := *ctx.Request().URL
+		query := target.Query()
+		query.Set("cursor", link.cursor)
+		query.Del("since")
+		query.Del("desc")
+		target.RawQuery = query.Encode()
+		ctx.Response().Header().Add("Link", fmt.Sprintf(`<%s>; rel="%s"`, target.RequestURI(), link.rel))
+	}
+}
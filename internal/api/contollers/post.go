@@ -1,15 +1,13 @@
 package controllers
 
 import (
-	"bytes"
-	"context"
-	"encoding/json"
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/gommon/log"
+	"github.com/rinatkh/db_forum/internal/logging"
 	"github.com/rinatkh/db_forum/internal/model/dto"
 	"github.com/rinatkh/db_forum/internal/service"
 	"github.com/sirupsen/logrus"
-	"strconv"
+	"net/http"
+	"time"
 )
 
 type PostController struct {
@@ -19,85 +17,82 @@ type PostController struct {
 
 func (c *PostController) CreatePosts(ctx echo.Context) error {
 	var request []*dto.Post
-	buf := new(bytes.Buffer)
-	_, err := buf.ReadFrom(ctx.Request().Body)
-	if err != nil {
-		log.Errorf("Unmarshal error: %s", err)
+	if err := ctx.Bind(&request); err != nil {
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
 		return err
 	}
-	err = json.Unmarshal(buf.Bytes(), &request)
-
 	soi := ctx.Param("slug_or_id")
-	response, err := c.registry.PostsService.CreatePosts(context.Background(), soi, request)
+	res, err := c.registry.PostsService.CreatePosts(ctx.Request().Context(), soi, request)
 	if err != nil {
 		return err
 	}
-
-	return ctx.JSON(response.Code, response.Data)
+	return ctx.JSON(http.StatusCreated, res)
 }
 
 func (c *PostController) GetPosts(ctx echo.Context) error {
-	soi := ctx.Param("slug_or_id")
-	sort := ctx.QueryParam("sort")
-	if sort == "" {
-		sort = "flat"
+	request := new(dto.GetPostsRequest)
+	if err := ctx.Bind(request); err != nil {
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
+		return err
 	}
-
-	sinceParam := ctx.QueryParam("since")
-	if sinceParam == "" {
-		sinceParam = "-1"
+	if request.Sort == "" {
+		request.Sort = "flat"
 	}
-
-	limitParam := ctx.QueryParam("limit")
-	if sinceParam == "" {
-		sinceParam = "100"
+	if request.Limit == 0 {
+		request.Limit = 100
 	}
-
-	since, _ := strconv.ParseInt(sinceParam, 10, 64)
-	desc, _ := strconv.ParseBool(ctx.QueryParam("desc"))
-	limit, _ := strconv.ParseInt(limitParam, 10, 64)
-
-	response, err := c.registry.PostsService.GetPosts(context.Background(), soi, sort, since, desc, limit)
+	if cursorMode(ctx) {
+		page, err := c.registry.PostsService.GetPostsPage(ctx.Request().Context(), request)
+		if err != nil {
+			return err
+		}
+		return writeShapedPage(ctx, c.registry, page, request.Fields, request.Embed, postRefs)
+	}
+	since := int64(-1)
+	if request.Since != nil {
+		since = *request.Since
+	}
+	res, err := c.registry.PostsService.GetPosts(ctx.Request().Context(), request.SlugOrID, request.Sort, since, request.Desc, request.Limit)
 	if err != nil {
 		return err
 	}
-
-	return ctx.JSON(response.Code, response.Data)
+	return writeList(ctx, c.registry, res, request.Fields, request.Embed, postRefs)
 }
 
 func (c *PostController) GetPostDetails(ctx echo.Context) error {
 	request := new(dto.GetPostDetailsRequest)
 	if err := ctx.Bind(request); err != nil {
-		c.log.Errorf("Bind error: %s", err)
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
 		return err
 	}
-	id, _ := strconv.ParseInt(ctx.Param("id"), 10, 64)
-	request.ID = id
 
-	response, err := c.registry.PostsService.GetPostDetails(context.Background(), request)
+	res, err := c.registry.PostsService.GetPostDetails(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
 
-	return ctx.JSON(response.Code, response.Data)
+	// Only the post itself knows when it was last changed.
+	modified := res.Post.Modified
+	if res.Author != nil || res.Thread != nil || res.Forum != nil {
+		modified = time.Time{}
+	}
+	return writeConditional(ctx, res, modified)
 }
 
 func (c *PostController) UpdatePost(ctx echo.Context) error {
 
 	request := new(dto.EditPostRequest)
 	if err := ctx.Bind(request); err != nil {
-		c.log.Errorf("Bind error: %s", err)
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
 		return err
 	}
-	id, _ := strconv.ParseInt(ctx.Param("id"), 10, 64)
-	request.ID = id
 
-	response, err := c.registry.PostsService.EditPost(context.Background(), request)
+	res, err := c.registry.PostsService.EditPost(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
 
-	return ctx.JSON(response.Code, response.Data)
+	return ctx.JSON(http.StatusOK, res)
 }
 
 func NewPostController(log *logrus.Entry, registry *service.Registry) *PostController {
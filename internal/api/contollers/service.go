@@ -1,15 +1,18 @@
 package controllers
 
 import (
-	"context"
 	"github.com/labstack/echo/v4"
 	"github.com/rinatkh/db_forum/internal/db"
+	"github.com/rinatkh/db_forum/internal/policy"
 	"github.com/sirupsen/logrus"
 	"net/http"
 )
 
 func (c *ServiceController) Clear(ctx echo.Context) error {
-	err := c.db.ServiceRepository.Delete(context.Background())
+	if err := c.policy.Authorize(ctx.Request().Context(), policy.ClearData, policy.Target{}); err != nil {
+		return err
+	}
+	err := c.db.ServiceRepository.Delete(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
@@ -17,18 +20,19 @@ func (c *ServiceController) Clear(ctx echo.Context) error {
 }
 
 type ServiceController struct {
-	log *logrus.Entry
-	db  *db.Repository
+	log    *logrus.Entry
+	db     *db.Repository
+	policy policy.Policy
 }
 
 func (c *ServiceController) Status(ctx echo.Context) error {
-	response, err := c.db.ServiceRepository.Status(context.Background())
+	response, err := c.db.ServiceRepository.Status(ctx.Request().Context())
 	if err != nil {
 		return err
 	}
 	return ctx.JSON(http.StatusOK, response)
 }
 
-func NewServiceController(log *logrus.Entry, db *db.Repository) *ServiceController {
-	return &ServiceController{log: log, db: db}
+func NewServiceController(log *logrus.Entry, db *db.Repository, policy policy.Policy) *ServiceController {
+	return &ServiceController{log: log, db: db, policy: policy}
 }
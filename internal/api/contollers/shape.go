@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/model/core"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	"github.com/rinatkh/db_forum/internal/service"
+)
+
+// embeddedKey is the key listed items carry the objects of embed= under.
+const embeddedKey = "_embedded"
+
+// refs names the author and the forum a listed item refers to.
+type refs[T any] func(item T) (author, forum string)
+
+func threadRefs(thread *core.Thread) (string, string) { return thread.Author, thread.Forum }
+
+func postRefs(post *core.Post) (string, string) { return post.Author, post.Forum }
+
+// fieldList splits a fields= or embed= parameter, already checked by the
+// validator; an empty parameter selects nothing and yields nil.
+func fieldList(param string) map[string]bool {
+	if param == "" {
+		return nil
+	}
+	names := strings.Split(param, ",")
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// project turns the struct value points to into a map of its JSON fields,
+// keeping only the selected ones unless selected is nil. Zero omitempty
+// fields are left out, as encoding/json does.
+func project(value interface{}, selected map[string]bool) map[string]interface{} {
+	val := reflect.Indirect(reflect.ValueOf(value))
+	typ := val.Type()
+	fields := make(map[string]interface{}, typ.NumField())
+	for n := 0; n < typ.NumField(); n++ {
+		tag := strings.Split(typ.Field(n).Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "" || name == "-" || (selected != nil && !selected[name]) {
+			continue
+		}
+		field := val.Field(n)
+		if len(tag) > 1 && tag[1] == "omitempty" && field.IsZero() {
+			continue
+		}
+		fields[name] = field.Interface()
+	}
+	return fields
+}
+
+// shapeOne trims a single entity to the fields of a fields= parameter.
+func shapeOne(value interface{}, fields string) interface{} {
+	if fields == "" {
+		return value
+	}
+	return project(value, fieldList(fields))
+}
+
+// shapeList trims listed items to the fields of a fields= parameter and
+// inlines the objects an embed= parameter asks for. Those are looked up with
+// one query per kind for the whole list, not one per item.
+func shapeList[T any](ctx context.Context, registry *service.Registry, items []T, fields, embed string, refsOf refs[T]) ([]map[string]interface{}, error) {
+	selected, embedded := fieldList(fields), fieldList(embed)
+
+	var authors, forums []string
+	if embedded != nil {
+		for _, item := range items {
+			author, forum := refsOf(item)
+			authors = append(authors, author)
+			forums = append(forums, forum)
+		}
+	}
+	users := make(map[string]*core.User)
+	if embedded["author"] {
+		found, err := registry.UserService.GetUsers(ctx, distinct(authors))
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range found {
+			users[strings.ToLower(user.Nickname)] = user
+		}
+	}
+	forumsBySlug := make(map[string]*core.Forum)
+	if embedded["forum"] {
+		found, err := registry.ForumService.GetForums(ctx, distinct(forums))
+		if err != nil {
+			return nil, err
+		}
+		for _, forum := range found {
+			forumsBySlug[strings.ToLower(forum.Slug)] = forum
+		}
+	}
+
+	shaped := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		projected := project(item, selected)
+		if embedded != nil {
+			author, forum := refsOf(item)
+			projected[embeddedKey] = &dto.Embedded{
+				Author: users[strings.ToLower(author)],
+				Forum:  forumsBySlug[strings.ToLower(forum)],
+			}
+		}
+		shaped = append(shaped, projected)
+	}
+	return shaped, nil
+}
+
+// distinct drops case-insensitive repetitions of nicknames or slugs.
+func distinct(keys []string) []string {
+	seen := make(map[string]bool, len(keys))
+	unique := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if lower := strings.ToLower(key); !seen[lower] {
+			seen[lower] = true
+			unique = append(unique, key)
+		}
+	}
+	return unique
+}
+
+// writeList answers with a listing, shaped if fields= or embed= is given.
+func writeList[T any](ctx echo.Context, registry *service.Registry, items []T, fields, embed string, refsOf refs[T]) error {
+	if fields == "" && embed == "" {
+		return ctx.JSON(http.StatusOK, items)
+	}
+	shaped, err := shapeList(ctx.Request().Context(), registry, items, fields, embed, refsOf)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, shaped)
+}
+
+// writeShapedPage is writeList for cursor mode listings.
+func writeShapedPage[T any](ctx echo.Context, registry *service.Registry, page *dto.Page[T], fields, embed string, refsOf refs[T]) error {
+	if fields == "" && embed == "" {
+		return writePage(ctx, page)
+	}
+	shaped, err := shapeList(ctx.Request().Context(), registry, page.Items, fields, embed, refsOf)
+	if err != nil {
+		return err
+	}
+	return writePage(ctx, &dto.Page[map[string]interface{}]{Items: shaped, NextCursor: page.NextCursor, PrevCursor: page.PrevCursor})
+}
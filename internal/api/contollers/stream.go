@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/events"
+	"github.com/rinatkh/db_forum/internal/logging"
+	"github.com/rinatkh/db_forum/internal/service"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+)
+
+const mimeEventStream = "text/event-stream"
+
+// StreamController pushes the changes of a thread to clients as they
+// happen: new posts, edited posts and vote counts.
+type StreamController struct {
+	log       *logrus.Entry
+	registry  *service.Registry
+	broker    events.Broker
+	keepalive time.Duration
+}
+
+// Events streams the changes as Server-Sent Events named after the event
+// type, with the event as JSON data.
+func (c *StreamController) Events(ctx echo.Context) error {
+	reqCtx := ctx.Request().Context()
+	thread, err := c.registry.ThreadService.GetThread(reqCtx, ctx.Param("slug_or_id"))
+	if err != nil {
+		return err
+	}
+	sub := c.broker.Subscribe(thread.ID)
+	defer sub.Close()
+
+	res := ctx.Response()
+	res.Header().Set(echo.HeaderContentType, mimeEventStream)
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	// Keeps nginx from buffering the stream.
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	ticks, stop := c.ticker()
+	defer stop()
+	for {
+		var err error
+		select {
+		case event, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+			var data []byte
+			if data, err = marshal(ctx, event); err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event.Type, data)
+		case <-ticks:
+			_, err = fmt.Fprint(res, ": keepalive\n\n")
+		case <-reqCtx.Done():
+			return nil
+		}
+		if err != nil {
+			logging.FromContext(reqCtx, c.log).Debugf("event stream of thread %d closed: %s", thread.ID, err)
+			return nil
+		}
+		res.Flush()
+	}
+}
+
+// WebSocket sends the changes as JSON text messages; messages from the
+// client are ignored.
+func (c *StreamController) WebSocket(ctx echo.Context) error {
+	reqCtx := ctx.Request().Context()
+	thread, err := c.registry.ThreadService.GetThread(reqCtx, ctx.Param("slug_or_id"))
+	if err != nil {
+		return err
+	}
+
+	// The streams are as public as the listings they mirror, so connections
+	// are accepted from any origin.
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+		sub := c.broker.Subscribe(thread.ID)
+		defer sub.Close()
+
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			var discarded []byte
+			for websocket.Message.Receive(ws, &discarded) == nil {
+			}
+		}()
+
+		ticks, stop := c.ticker()
+		defer stop()
+		for {
+			var err error
+			select {
+			case event, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				var data []byte
+				if data, err = marshal(ctx, event); err != nil {
+					logging.FromContext(reqCtx, c.log).Errorf("unable to encode event: %s", err)
+					return
+				}
+				err = websocket.Message.Send(ws, string(data))
+			case <-ticks:
+				ws.PayloadType = websocket.PingFrame
+				_, err = ws.Write(nil)
+				ws.PayloadType = websocket.TextFrame
+			case <-closed:
+				return
+			}
+			if err != nil {
+				logging.FromContext(reqCtx, c.log).Debugf("websocket of thread %d closed: %s", thread.ID, err)
+				return
+			}
+		}
+	}}
+	server.ServeHTTP(ctx.Response(), ctx.Request())
+	return nil
+}
+
+// ticker paces the keepalives that stop proxies from closing idle streams;
+// it never fires when they are disabled.
+func (c *StreamController) ticker() (<-chan time.Time, func()) {
+	if c.keepalive <= 0 {
+		return nil, func() {}
+	}
+	ticker := time.NewTicker(c.keepalive)
+	return ticker.C, ticker.Stop
+}
+
+func NewStreamController(log *logrus.Entry, registry *service.Registry, broker events.Broker, keepalive time.Duration) *StreamController {
+	return &StreamController{log: log, registry: registry, broker: broker, keepalive: keepalive}
+}
@@ -1,11 +1,12 @@
 package controllers
 
 import (
-	"context"
 	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/logging"
 	"github.com/rinatkh/db_forum/internal/model/dto"
 	"github.com/rinatkh/db_forum/internal/service"
 	"github.com/sirupsen/logrus"
+	"net/http"
 )
 
 type ThreadController struct {
@@ -17,59 +18,78 @@ func (c *ThreadController) CreateThread(ctx echo.Context) error {
 	request := new(dto.CreateThreadRequest)
 
 	if err := ctx.Bind(request); err != nil {
-		c.log.Errorf("Bind error: %s", err)
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
 		return err
 	}
-	request.Forum = ctx.Param("slug")
 
-	response, err := c.registry.ThreadService.CreateThread(context.Background(), request)
+	res, err := c.registry.ThreadService.CreateThread(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
 
-	return ctx.JSON(response.Code, response.Data)
+	return ctx.JSON(http.StatusCreated, res)
 }
 
 func (c *ThreadController) CountVote(ctx echo.Context) error {
 	request := &dto.EditVoteRequest{}
 	if err := ctx.Bind(request); err != nil {
-		c.log.Errorf("Bind error: %s", err)
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
 		return err
 	}
 	soi := ctx.Param("slug_or_id")
-	response, err := c.registry.ThreadService.CountVote(context.Background(), soi, request)
+	res, err := c.registry.ThreadService.CountVote(ctx.Request().Context(), soi, request)
 	if err != nil {
 		return err
 	}
 
-	return ctx.JSON(response.Code, response.Data)
+	return ctx.JSON(http.StatusOK, res)
 }
 
 func (c *ThreadController) GetThread(ctx echo.Context) error {
-	soi := ctx.Param("slug_or_id")
+	request := new(dto.GetThreadRequest)
+	if err := ctx.Bind(request); err != nil {
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
+		return err
+	}
 
-	response, err := c.registry.ThreadService.GetThread(context.Background(), soi)
+	res, err := c.registry.ThreadService.GetThread(ctx.Request().Context(), request.SlugOrID)
 	if err != nil {
 		return err
 	}
 
-	return ctx.JSON(response.Code, response.Data)
+	return writeConditional(ctx, shapeOne(res, request.Fields), res.Modified)
 }
 
 func (c *ThreadController) EditThread(ctx echo.Context) error {
 	request := &dto.EditThreadRequest{}
 	if err := ctx.Bind(request); err != nil {
-		c.log.Errorf("Bind error: %s", err)
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
+		return err
+	}
+	soi := ctx.Param("slug_or_id")
+
+	res, err := c.registry.ThreadService.EditThread(ctx.Request().Context(), soi, request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (c *ThreadController) LockThread(ctx echo.Context) error {
+	request := &dto.LockThreadRequest{}
+	if err := ctx.Bind(request); err != nil {
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
 		return err
 	}
 	soi := ctx.Param("slug_or_id")
 
-	response, err := c.registry.ThreadService.EditThread(context.Background(), soi, request)
+	res, err := c.registry.ThreadService.LockThread(ctx.Request().Context(), soi, request)
 	if err != nil {
 		return err
 	}
 
-	return ctx.JSON(response.Code, response.Data)
+	return ctx.JSON(http.StatusOK, res)
 }
 
 func NewThreadController(log *logrus.Entry, registry *service.Registry) *ThreadController {
@@ -1,11 +1,13 @@
 package controllers
 
 import (
-	"context"
 	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/logging"
 	"github.com/rinatkh/db_forum/internal/model/dto"
 	"github.com/rinatkh/db_forum/internal/service"
 	"github.com/sirupsen/logrus"
+	"net/http"
+	"time"
 )
 
 type UserController struct {
@@ -17,48 +19,60 @@ func (c *UserController) GetUserProfile(ctx echo.Context) error {
 	request := new(dto.GetUserProfileRequest)
 
 	if err := ctx.Bind(request); err != nil {
-		c.log.Errorf("Bind error: %s", err)
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
 		return err
 	}
-	request.Nickname = ctx.Param("nickname")
 
-	response, err := c.registry.UserService.GetUserProfile(context.Background(), request)
+	res, err := c.registry.UserService.GetUserProfile(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
-	return ctx.JSON(response.Code, response.Data)
+	return writeConditional(ctx, shapeOne(res, request.Fields), time.Time{})
 }
 
 func (c *UserController) CreateUser(ctx echo.Context) error {
 	request := new(dto.CreateUserRequest)
 
 	if err := ctx.Bind(request); err != nil {
-		c.log.Errorf("Bind error: %s", err)
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
 		return err
 	}
-	request.Nickname = ctx.Param("nickname")
 
-	response, err := c.registry.UserService.CreateUser(context.Background(), request)
+	res, err := c.registry.UserService.CreateUser(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
-	return ctx.JSON(response.Code, response.Data)
+	return ctx.JSON(http.StatusCreated, res)
 }
 
 func (c *UserController) EditUserProfile(ctx echo.Context) error {
 	request := new(dto.EditUserProfileRequest)
 
 	if err := ctx.Bind(request); err != nil {
-		c.log.Errorf("Bind error: %s", err)
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
 		return err
 	}
-	request.Nickname = ctx.Param("nickname")
 
-	response, err := c.registry.UserService.EditUserProfile(context.Background(), request)
+	res, err := c.registry.UserService.EditUserProfile(ctx.Request().Context(), request)
 	if err != nil {
 		return err
 	}
-	return ctx.JSON(response.Code, response.Data)
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (c *UserController) BanUser(ctx echo.Context) error {
+	request := new(dto.BanUserRequest)
+
+	if err := ctx.Bind(request); err != nil {
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
+		return err
+	}
+
+	res, err := c.registry.UserService.BanUser(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
 }
 
 func NewUserController(log *logrus.Entry, registry *service.Registry) *UserController {
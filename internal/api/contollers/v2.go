@@ -0,0 +1,349 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/logging"
+	"github.com/rinatkh/db_forum/internal/model/core"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	dtov2 "github.com/rinatkh/db_forum/internal/model/dto/v2"
+	"github.com/rinatkh/db_forum/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+// V2Controller serves the /api/v2 routes. They take the same requests as
+// their v1 counterparts and call the same services, but answer with the
+// resources of dtov2; listings are always paginated by cursor.
+type V2Controller struct {
+	log      *logrus.Entry
+	registry *service.Registry
+}
+
+func (c *V2Controller) bind(ctx echo.Context, request interface{}) error {
+	if err := ctx.Bind(request); err != nil {
+		logging.FromContext(ctx.Request().Context(), c.log).Errorf("Bind error: %s", err)
+		return err
+	}
+	return nil
+}
+
+// threads converts threads with their activity, looked up in one query.
+func (c *V2Controller) threads(ctx context.Context, threads ...*core.Thread) ([]*dtov2.Thread, error) {
+	ids := make([]int64, 0, len(threads))
+	for _, thread := range threads {
+		ids = append(ids, thread.ID)
+	}
+	activity, err := c.registry.ThreadService.GetThreadsActivity(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*core.ThreadActivity, len(activity))
+	for _, a := range activity {
+		byID[a.ID] = a
+	}
+
+	converted := make([]*dtov2.Thread, 0, len(threads))
+	for _, thread := range threads {
+		converted = append(converted, dtov2.NewThread(thread, byID[thread.ID]))
+	}
+	return converted, nil
+}
+
+// posts converts posts with their depth, looked up in one query.
+func (c *V2Controller) posts(ctx context.Context, posts ...*core.Post) ([]*dtov2.Post, error) {
+	ids := make([]int64, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	depths, err := c.registry.PostsService.GetPostsDepth(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]int64, len(depths))
+	for _, d := range depths {
+		byID[d.ID] = d.Depth
+	}
+
+	converted := make([]*dtov2.Post, 0, len(posts))
+	for _, post := range posts {
+		converted = append(converted, dtov2.NewPost(post, byID[post.ID]))
+	}
+	return converted, nil
+}
+
+func (c *V2Controller) writeThread(ctx echo.Context, code int, thread *core.Thread) error {
+	converted, err := c.threads(ctx.Request().Context(), thread)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(code, converted[0])
+}
+
+func (c *V2Controller) writePost(ctx echo.Context, post *core.Post) error {
+	converted, err := c.posts(ctx.Request().Context(), post)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, converted[0])
+}
+
+// writeListV2 answers with a page of a listing in the v2 envelope and, like
+// v1 cursor mode, advertises its neighbours as Link headers.
+func writeListV2[T any](ctx echo.Context, items []T, limit int64, next, prev string) error {
+	if items == nil {
+		items = []T{}
+	}
+	writeLinks(ctx, next, prev)
+	return ctx.JSON(http.StatusOK, &dtov2.List[T]{
+		Data:       items,
+		Pagination: dtov2.Pagination{Limit: limit, Count: len(items), NextCursor: next, PrevCursor: prev},
+	})
+}
+
+func (c *V2Controller) CreateUser(ctx echo.Context) error {
+	request := new(dto.CreateUserRequest)
+	if err := c.bind(ctx, request); err != nil {
+		return err
+	}
+
+	res, err := c.registry.UserService.CreateUser(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusCreated, dtov2.NewUser(res))
+}
+
+func (c *V2Controller) GetUser(ctx echo.Context) error {
+	request := new(dto.GetUserProfileRequest)
+	if err := c.bind(ctx, request); err != nil {
+		return err
+	}
+
+	res, err := c.registry.UserService.GetUserProfile(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, dtov2.NewUser(res))
+}
+
+func (c *V2Controller) EditUser(ctx echo.Context) error {
+	request := new(dto.EditUserProfileRequest)
+	if err := c.bind(ctx, request); err != nil {
+		return err
+	}
+
+	res, err := c.registry.UserService.EditUserProfile(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, dtov2.NewUser(res))
+}
+
+func (c *V2Controller) CreateForum(ctx echo.Context) error {
+	request := new(dto.CreateForumRequest)
+	if err := c.bind(ctx, request); err != nil {
+		return err
+	}
+
+	res, err := c.registry.ForumService.CreateForum(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusCreated, dtov2.NewForum(res))
+}
+
+func (c *V2Controller) GetForum(ctx echo.Context) error {
+	request := new(dto.GetForumRequest)
+	if err := c.bind(ctx, request); err != nil {
+		return err
+	}
+
+	res, err := c.registry.ForumService.GetForum(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, dtov2.NewForum(res))
+}
+
+func (c *V2Controller) GetForumUsers(ctx echo.Context) error {
+	request := new(dtov2.GetForumListRequest)
+	if err := c.bind(ctx, request); err != nil {
+		return err
+	}
+	if request.Limit == 0 {
+		request.Limit = 100
+	}
+
+	page, err := c.registry.ForumService.GetForumUsersPage(ctx.Request().Context(), &dto.GetForumUsersRequest{
+		Slug: request.Slug, Limit: request.Limit, Desc: request.Desc, Cursor: request.Cursor,
+	})
+	if err != nil {
+		return err
+	}
+	users := make([]*dtov2.User, 0, len(page.Items))
+	for _, user := range page.Items {
+		users = append(users, dtov2.NewUser(user))
+	}
+	return writeListV2(ctx, users, request.Limit, page.NextCursor, page.PrevCursor)
+}
+
+func (c *V2Controller) GetForumThreads(ctx echo.Context) error {
+	request := new(dtov2.GetForumListRequest)
+	if err := c.bind(ctx, request); err != nil {
+		return err
+	}
+	if request.Limit == 0 {
+		request.Limit = 100
+	}
+
+	page, err := c.registry.ForumService.GetForumThreadsPage(ctx.Request().Context(), &dto.GetForumThreadsRequest{
+		Slug: request.Slug, Limit: request.Limit, Desc: request.Desc, Cursor: request.Cursor,
+	})
+	if err != nil {
+		return err
+	}
+	threads, err := c.threads(ctx.Request().Context(), page.Items...)
+	if err != nil {
+		return err
+	}
+	return writeListV2(ctx, threads, request.Limit, page.NextCursor, page.PrevCursor)
+}
+
+func (c *V2Controller) CreateThread(ctx echo.Context) error {
+	request := new(dto.CreateThreadRequest)
+	if err := c.bind(ctx, request); err != nil {
+		return err
+	}
+
+	res, err := c.registry.ThreadService.CreateThread(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return c.writeThread(ctx, http.StatusCreated, res)
+}
+
+func (c *V2Controller) GetThread(ctx echo.Context) error {
+	res, err := c.registry.ThreadService.GetThread(ctx.Request().Context(), ctx.Param("slug_or_id"))
+	if err != nil {
+		return err
+	}
+	return c.writeThread(ctx, http.StatusOK, res)
+}
+
+func (c *V2Controller) EditThread(ctx echo.Context) error {
+	request := new(dto.EditThreadRequest)
+	if err := c.bind(ctx, request); err != nil {
+		return err
+	}
+
+	res, err := c.registry.ThreadService.EditThread(ctx.Request().Context(), ctx.Param("slug_or_id"), request)
+	if err != nil {
+		return err
+	}
+	return c.writeThread(ctx, http.StatusOK, res)
+}
+
+func (c *V2Controller) Vote(ctx echo.Context) error {
+	request := new(dto.EditVoteRequest)
+	if err := c.bind(ctx, request); err != nil {
+		return err
+	}
+
+	res, err := c.registry.ThreadService.CountVote(ctx.Request().Context(), ctx.Param("slug_or_id"), request)
+	if err != nil {
+		return err
+	}
+	return c.writeThread(ctx, http.StatusOK, res)
+}
+
+func (c *V2Controller) CreatePosts(ctx echo.Context) error {
+	var request []*dto.Post
+	if err := c.bind(ctx, &request); err != nil {
+		return err
+	}
+
+	res, err := c.registry.PostsService.CreatePosts(ctx.Request().Context(), ctx.Param("slug_or_id"), request)
+	if err != nil {
+		return err
+	}
+	posts, err := c.posts(ctx.Request().Context(), res...)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusCreated, posts)
+}
+
+func (c *V2Controller) GetThreadPosts(ctx echo.Context) error {
+	request := new(dtov2.GetThreadPostsRequest)
+	if err := c.bind(ctx, request); err != nil {
+		return err
+	}
+	if request.Sort == "" {
+		request.Sort = "flat"
+	}
+	if request.Limit == 0 {
+		request.Limit = 100
+	}
+
+	page, err := c.registry.PostsService.GetPostsPage(ctx.Request().Context(), &dto.GetPostsRequest{
+		SlugOrID: request.SlugOrID, Sort: request.Sort, Limit: request.Limit, Desc: request.Desc, Cursor: request.Cursor,
+	})
+	if err != nil {
+		return err
+	}
+	posts, err := c.posts(ctx.Request().Context(), page.Items...)
+	if err != nil {
+		return err
+	}
+	return writeListV2(ctx, posts, request.Limit, page.NextCursor, page.PrevCursor)
+}
+
+func (c *V2Controller) GetPost(ctx echo.Context) error {
+	request := new(dto.GetPostDetailsRequest)
+	if err := c.bind(ctx, request); err != nil {
+		return err
+	}
+
+	res, err := c.registry.PostsService.GetPostDetails(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	posts, err := c.posts(ctx.Request().Context(), res.Post)
+	if err != nil {
+		return err
+	}
+	details := &dtov2.PostDetails{Post: posts[0]}
+	if res.Author != nil {
+		details.Author = dtov2.NewUser(res.Author)
+	}
+	if res.Thread != nil {
+		threads, err := c.threads(ctx.Request().Context(), res.Thread)
+		if err != nil {
+			return err
+		}
+		details.Thread = threads[0]
+	}
+	if res.Forum != nil {
+		details.Forum = dtov2.NewForum(res.Forum)
+	}
+	return ctx.JSON(http.StatusOK, details)
+}
+
+func (c *V2Controller) EditPost(ctx echo.Context) error {
+	request := new(dto.EditPostRequest)
+	if err := c.bind(ctx, request); err != nil {
+		return err
+	}
+
+	res, err := c.registry.PostsService.EditPost(ctx.Request().Context(), request)
+	if err != nil {
+		return err
+	}
+	return c.writePost(ctx, res)
+}
+
+func NewV2Controller(log *logrus.Entry, registry *service.Registry) *V2Controller {
+	return &V2Controller{log: log, registry: registry}
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/codec"
+	"github.com/rinatkh/db_forum/internal/logging"
+)
+
+const (
+	mimeMsgpack  = "application/msgpack"
+	mimeXMsgpack = "application/x-msgpack"
+)
+
+// Compression is a content coding offered for responses of at least MinSize
+// bytes.
+type Compression struct {
+	codec.Compression
+	MinSize int
+}
+
+// Encodings are the representations EncodingMiddleware negotiates.
+type Encodings struct {
+	// MsgPack offers MessagePack next to JSON.
+	MsgPack bool
+	// Compressions answer Accept-Encoding, the preferred first.
+	Compressions []Compression
+	// Decompressions are the codings accepted in Content-Encoding of request
+	// bodies, which may inflate to at most MaxRequestSize bytes.
+	Decompressions []codec.Compression
+	MaxRequestSize int64
+}
+
+// EncodingMiddleware inflates compressed request bodies and answers in the
+// media type and content coding preferred by the Accept and Accept-Encoding
+// headers. JSON responses are transcoded to MessagePack and compressed as a
+// whole, so only requests that negotiate either are buffered. The ETag of a
+// transformed response is weakened, as it no longer names these bytes.
+func (svc *APIService) EncodingMiddleware(encodings Encodings) echo.MiddlewareFunc {
+	mediaTypes := []string{echo.MIMEApplicationJSON}
+	if encodings.MsgPack {
+		mediaTypes = append(mediaTypes, mimeMsgpack, mimeXMsgpack)
+	}
+	codings := make([]string, 0, len(encodings.Compressions))
+	for _, compression := range encodings.Compressions {
+		codings = append(codings, compression.Name())
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			req := ctx.Request()
+			if coding := req.Header.Get(echo.HeaderContentEncoding); coding != "" && coding != "identity" {
+				if err := decompressBody(req, coding, encodings); err != nil {
+					return err
+				}
+			}
+
+			header := ctx.Response().Header()
+			if encodings.MsgPack {
+				header.Add(echo.HeaderVary, echo.HeaderAccept)
+			}
+			if len(codings) > 0 {
+				header.Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+			}
+			mediaType := negotiate(req.Header.Get(echo.HeaderAccept), mediaTypes, "*/*")
+			var compression *Compression
+			if coding := negotiate(req.Header.Get(echo.HeaderAcceptEncoding), codings, ""); coding != "" {
+				compression = &encodings.Compressions[indexOf(codings, coding)]
+			}
+			msgpack := mediaType == mimeMsgpack || mediaType == mimeXMsgpack
+			if (!msgpack && compression == nil) || svc.isStream(ctx) {
+				return next(ctx)
+			}
+
+			res := ctx.Response()
+			buffer := &responseBuffer{ResponseWriter: res.Writer}
+			res.Writer = buffer
+			// Errors are rendered here so that they are encoded as well; the
+			// error handler skips responses that are already committed.
+			err := next(ctx)
+			if err != nil {
+				ctx.Error(err)
+			}
+			res.Writer = buffer.ResponseWriter
+
+			body := buffer.body.Bytes()
+			transformed := false
+			if msgpack && len(body) > 0 && strings.HasPrefix(header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+				packed, packErr := codec.JSONToMsgpack(nil, body)
+				if packErr != nil {
+					logging.FromContext(req.Context(), svc.log).Errorf("unable to encode response as MessagePack: %s", packErr)
+				} else {
+					body, transformed = packed, true
+					header.Set(echo.HeaderContentType, mediaType)
+				}
+			}
+			if compression != nil && len(body) >= compression.MinSize && len(body) > 0 && header.Get(echo.HeaderContentEncoding) == "" {
+				compressed, compressErr := compression.Compress(body)
+				if compressErr != nil {
+					logging.FromContext(req.Context(), svc.log).Errorf("unable to compress response: %s", compressErr)
+				} else {
+					body, transformed = compressed, true
+					header.Set(echo.HeaderContentEncoding, compression.Name())
+				}
+			}
+			if transformed {
+				header.Del(echo.HeaderContentLength)
+				if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+					header.Set("ETag", "W/"+etag)
+				}
+			}
+			buffer.body.Reset()
+			buffer.body.Write(body)
+			if flushErr := buffer.flush(); flushErr != nil {
+				logging.FromContext(req.Context(), svc.log).Errorf("unable to write response: %s", flushErr)
+			}
+			return err
+		}
+	}
+}
+
+func decompressBody(req *http.Request, coding string, encodings Encodings) error {
+	var decompression codec.Compression
+	for _, candidate := range encodings.Decompressions {
+		if strings.EqualFold(candidate.Name(), coding) {
+			decompression = candidate
+		}
+	}
+	if decompression == nil {
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, "Unsupported Content-Encoding "+coding)
+	}
+	var compressed []byte
+	if req.Body != nil {
+		// The compressed body is held to the limit of the inflated one, so
+		// that no more than that is read into memory.
+		var err error
+		if compressed, err = io.ReadAll(io.LimitReader(req.Body, encodings.MaxRequestSize+1)); err != nil {
+			return err
+		}
+		if int64(len(compressed)) > encodings.MaxRequestSize {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Request body is too large")
+		}
+	}
+	body, err := decompression.Decompress(compressed, encodings.MaxRequestSize)
+	if errors.Is(err, codec.ErrTooLarge) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Request body is too large")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Malformed "+coding+" request body")
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Del(echo.HeaderContentEncoding)
+	req.Header.Set(echo.HeaderContentLength, strconv.Itoa(len(body)))
+	return nil
+}
+
+// negotiate picks the offer with the highest quality in an Accept-style
+// header, earlier offers winning ties. An offer may also be matched by
+// wildcard, or for media types by its type followed by "/*". An empty header
+// accepts the first offer if wildcard is "*/*" and nothing otherwise, which
+// is how Accept and Accept-Encoding treat a missing header.
+func negotiate(header string, offers []string, wildcard string) string {
+	if strings.TrimSpace(header) == "" {
+		if wildcard == "*/*" && len(offers) > 0 {
+			return offers[0]
+		}
+		return ""
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, part := range strings.Split(header, ",") {
+			name, params, _ := strings.Cut(part, ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			var match int
+			switch {
+			case name == offer:
+				match = 2
+			case strings.HasSuffix(name, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(name, "*")):
+				match = 1
+			case name == "*" || name == "*/*":
+				match = 0
+			default:
+				continue
+			}
+			if match > specificity {
+				q, specificity = quality(params), match
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+func quality(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if ok && strings.EqualFold(key, "q") {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return 0
+			}
+			return q
+		}
+	}
+	return 1
+}
+
+func indexOf(values []string, value string) int {
+	for n, candidate := range values {
+		if candidate == value {
+			return n
+		}
+	}
+	return -1
+}
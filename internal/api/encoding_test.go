@@ -0,0 +1,43 @@
+package api
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	mediaTypes := []string{"application/json", "application/msgpack"}
+	codings := []string{"zstd", "gzip"}
+
+	tests := []struct {
+		name     string
+		header   string
+		offers   []string
+		wildcard string
+		want     string
+	}{
+		{"no accept", "", mediaTypes, "*/*", "application/json"},
+		{"no accept encoding", " ", codings, "", ""},
+		{"exact", "application/msgpack", mediaTypes, "*/*", "application/msgpack"},
+		{"case", "Application/MsgPack", mediaTypes, "*/*", "application/msgpack"},
+		{"nothing acceptable", "text/html", mediaTypes, "*/*", ""},
+		{"any", "*/*", mediaTypes, "*/*", "application/json"},
+		{"type wildcard", "text/*, application/*;q=0.5", mediaTypes, "*/*", "application/json"},
+		{"higher q", "application/json;q=0.5, application/msgpack", mediaTypes, "*/*", "application/msgpack"},
+		{"q with spaces", "application/json; q=0.2, application/msgpack ; Q=0.9", mediaTypes, "*/*", "application/msgpack"},
+		{"tie goes to the earlier offer", "application/msgpack;q=0.8, application/json;q=0.8", mediaTypes, "*/*", "application/json"},
+		{"exact beats wildcard", "*/*;q=0.9, application/json;q=0.1", mediaTypes, "*/*", "application/msgpack"},
+		{"type beats any", "*/*, application/*;q=0", mediaTypes, "*/*", ""},
+		{"q zero refuses", "application/msgpack;q=0", mediaTypes, "*/*", ""},
+		{"bad q refuses", "application/msgpack;q=high, application/json;q=0.1", mediaTypes, "*/*", "application/json"},
+		{"coding", "gzip, deflate", codings, "", "gzip"},
+		{"coding tie", "gzip, zstd", codings, "", "zstd"},
+		{"coding q", "zstd;q=0.5, gzip", codings, "", "gzip"},
+		{"coding any", "*", codings, "", "zstd"},
+		{"coding any but one", "*, zstd;q=0", codings, "", "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiate(tt.header, tt.offers, tt.wildcard); got != tt.want {
+				t.Errorf("negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/logging"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	dtov2 "github.com/rinatkh/db_forum/internal/model/dto/v2"
+)
+
+// statusClientClosedRequest is the nginx convention for requests the client
+// abandoned before the response was ready.
+const statusClientClosedRequest = 499
+
+// pgQueryCanceled is the SQLSTATE of statements stopped by statement_timeout.
+const pgQueryCanceled = "57014"
+
+var domainStatus = map[domain.Kind]int{
+	domain.KindNotFound:     http.StatusNotFound,
+	domain.KindConflict:     http.StatusConflict,
+	domain.KindValidation:   http.StatusBadRequest,
+	domain.KindForbidden:    http.StatusForbidden,
+	domain.KindUnauthorized: http.StatusUnauthorized,
+	domain.KindRateLimited:  http.StatusTooManyRequests,
+}
+
+// HTTPErrorHandler renders every error returned by a handler as the swagger
+// error body. Domain errors map onto their status codes, conflicts carrying
+// the existing entity return it instead, and anything else becomes a 500.
+// Requests to /api/v2 get the v2 error object instead.
+func (svc *APIService) HTTPErrorHandler(err error, ctx echo.Context) {
+	if ctx.Response().Committed {
+		return
+	}
+
+	code, body := svc.errorResponse(err, ctx)
+	if isV2(ctx.Request()) {
+		body = errorResponseV2(err, ctx, code, body)
+	}
+
+	if ctx.Request().Method == http.MethodHead {
+		err = ctx.NoContent(code)
+	} else {
+		err = ctx.JSON(code, body)
+	}
+	if err != nil {
+		logging.FromContext(ctx.Request().Context(), svc.log).Errorf("unable to write error response: %s", err)
+	}
+}
+
+func (svc *APIService) errorResponse(err error, ctx echo.Context) (int, interface{}) {
+	if domainErr, ok := domain.As(err); ok {
+		code, ok := domainStatus[domainErr.Kind]
+		if !ok {
+			code = http.StatusInternalServerError
+		}
+		if domainErr.Kind == domain.KindUnauthorized {
+			ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+		}
+		if domainErr.Kind == domain.KindRateLimited {
+			seconds := int64((domainErr.RetryAfter + time.Second - 1) / time.Second)
+			ctx.Response().Header().Set(echo.HeaderRetryAfter, strconv.FormatInt(seconds, 10))
+		}
+		if domainErr.Existing != nil {
+			return code, domainErr.Existing
+		}
+		response := dto.ErrorResponse{Message: domainErr.Message}
+		for _, field := range domainErr.Fields {
+			response.Errors = append(response.Errors, dto.FieldError{Field: field.Field, Message: field.Message})
+		}
+		return code, response
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		if httpErr.Internal != nil {
+			logging.FromContext(ctx.Request().Context(), svc.log).Warnf("http error %d: %s", httpErr.Code, httpErr.Internal)
+		}
+		return httpErr.Code, dto.ErrorResponse{Message: fmt.Sprint(httpErr.Message)}
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &pgErr) && pgErr.Code == pgQueryCanceled) {
+		logging.FromContext(ctx.Request().Context(), svc.log).Warnf("%s %s timed out: %s", ctx.Request().Method, ctx.Path(), err)
+		return http.StatusServiceUnavailable, dto.ErrorResponse{Message: "request timed out"}
+	}
+	if errors.Is(err, context.Canceled) {
+		logging.FromContext(ctx.Request().Context(), svc.log).Debugf("%s %s canceled by client", ctx.Request().Method, ctx.Path())
+		return statusClientClosedRequest, dto.ErrorResponse{Message: "request canceled"}
+	}
+
+	logging.FromContext(ctx.Request().Context(), svc.log).Errorf("unexpected error on %s %s: %s", ctx.Request().Method, ctx.Path(), err)
+	return http.StatusInternalServerError, dto.ErrorResponse{Message: http.StatusText(http.StatusInternalServerError)}
+}
+
+// isV2 tells requests to the v2 API apart by their URL, which also covers
+// those no route matched.
+func isV2(req *http.Request) bool {
+	return req.URL.Path == v2Prefix || strings.HasPrefix(req.URL.Path, v2Prefix+"/")
+}
+
+// errorResponseV2 turns the v1 error body into the v2 error object. Its code
+// is the kind of a domain error or else the snake_case status text, e.g.
+// not_found or service_unavailable.
+func errorResponseV2(err error, ctx echo.Context, code int, body interface{}) dtov2.ErrorResponse {
+	apiErr := dtov2.Error{
+		Code:      strings.ReplaceAll(strings.ToLower(http.StatusText(code)), " ", "_"),
+		RequestID: ctx.Response().Header().Get(requestIDHeader),
+	}
+	if code == statusClientClosedRequest {
+		apiErr.Code = "client_closed_request"
+	}
+	if domainErr, ok := domain.As(err); ok {
+		apiErr.Code = domainErr.Kind.String()
+		apiErr.Message = domainErr.Message
+		apiErr.Entity = domainErr.Entity
+		apiErr.Key = domainErr.Key
+		for _, field := range domainErr.Fields {
+			apiErr.Fields = append(apiErr.Fields, dtov2.FieldError{Field: field.Field, Message: field.Message})
+		}
+	} else if response, ok := body.(dto.ErrorResponse); ok {
+		apiErr.Message = response.Message
+	}
+	return dtov2.ErrorResponse{Error: apiErr}
+}
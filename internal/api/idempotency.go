@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/auth"
+	"github.com/rinatkh/db_forum/internal/db"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/logging"
+	"github.com/rinatkh/db_forum/internal/model/core"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	idempotencyStoreTimeout  = 5 * time.Second
+)
+
+// IdempotencyMiddleware makes retries of a request with the same
+// Idempotency-Key header return the response of the first attempt instead of
+// running it again. Keys are scoped to the authenticated user, or shared by
+// everyone in open mode, and a key reused for a different request is refused
+// with 422. Responses of abandoned requests and those retryable answers may
+// change for, such as 5xx, 429 or 401, are not kept, so that their retries
+// run again.
+func (svc *APIService) IdempotencyMiddleware(repo db.IdempotencyRepository, ttl time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if ttl <= 0 {
+			return next
+		}
+		return func(ctx echo.Context) error {
+			req := ctx.Request()
+			key := req.Header.Get(idempotencyKeyHeader)
+			if key == "" {
+				return next(ctx)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return domain.InvalidFields(domain.FieldError{Field: idempotencyKeyHeader, Message: "must be at most 255 characters"})
+			}
+
+			var body []byte
+			if req.Body != nil {
+				var err error
+				if body, err = io.ReadAll(req.Body); err != nil {
+					return err
+				}
+				req.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			hash := sha256.New()
+			hash.Write([]byte(req.Method + " " + req.URL.RequestURI() + "\n"))
+			hash.Write(body)
+			requestHash := hash.Sum(nil)
+
+			var scope string
+			if identity, ok := auth.FromContext(req.Context()); ok {
+				scope = identity.Nickname
+			}
+
+			stored, err := repo.ReserveKey(req.Context(), scope, key, requestHash, time.Now().Add(ttl))
+			if err != nil {
+				return err
+			}
+			if stored != nil {
+				if !bytes.Equal(stored.RequestHash, requestHash) {
+					return echo.NewHTTPError(http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+				}
+				if stored.Status == 0 {
+					return domain.Conflict("request", key, nil, "A request with this Idempotency-Key is still in progress")
+				}
+				ctx.Response().Header().Set(idempotentReplayedHeader, "true")
+				return ctx.Blob(stored.Status, stored.ContentType, stored.Body)
+			}
+
+			res := ctx.Response()
+			recorder := &bodyRecorder{ResponseWriter: res.Writer, limit: math.MaxInt}
+			res.Writer = recorder
+			defer func() { res.Writer = recorder.ResponseWriter }()
+
+			// Errors are rendered here so that the response can be stored;
+			// the error handler skips responses that are already committed.
+			handlerErr := next(ctx)
+			if handlerErr != nil {
+				ctx.Error(handlerErr)
+			}
+
+			// The request context may be done by now, but the outcome still
+			// has to be recorded.
+			storeCtx, cancel := context.WithTimeout(logging.WithLogger(context.Background(), logging.FromContext(req.Context(), svc.log)), idempotencyStoreTimeout)
+			defer cancel()
+			if retryable(res.Status) || req.Context().Err() != nil {
+				err = repo.ReleaseKey(storeCtx, scope, key)
+			} else {
+				err = repo.CompleteKey(storeCtx, scope, key, &core.IdempotentResponse{
+					Status:      res.Status,
+					ContentType: res.Header().Get(echo.HeaderContentType),
+					Body:        recorder.body.Bytes(),
+				})
+			}
+			if err != nil {
+				logging.FromContext(req.Context(), svc.log).Errorf("unable to store response for idempotency key: %s", err)
+			}
+			return handlerErr
+		}
+	}
+}
+
+// retryable reports whether a retry of a request answered with status may
+// succeed without changing the request: server errors, rate limits, timeouts
+// and missing or insufficient credentials.
+func retryable(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return status >= http.StatusInternalServerError
+}
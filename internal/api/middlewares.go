@@ -1,48 +1,216 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	mathrand "math/rand"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
 	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/auth"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/logging"
+	"github.com/rinatkh/db_forum/internal/ratelimit"
+	"github.com/rinatkh/db_forum/internal/service"
 	"github.com/sirupsen/logrus"
-	"time"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const requestIDHeader = echo.HeaderXRequestID
+
+var emailRe = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// bodyRecorder keeps the first limit bytes written to the response.
+type bodyRecorder struct {
+	http.ResponseWriter
+	body  bytes.Buffer
+	limit int
+}
+
+func (r *bodyRecorder) Write(b []byte) (int, error) {
+	if room := r.limit - r.body.Len(); room > 0 {
+		if len(b) < room {
+			room = len(b)
+		}
+		r.body.Write(b[:room])
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *bodyRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// isStream reports whether the request is routed to an event stream.
+func (svc *APIService) isStream(ctx echo.Context) bool {
+	return svc.streams[ctx.Path()]
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
+
+// RequestIDMiddleware propagates or generates X-Request-ID and puts a logger
+// tagged with it into the request context, for services and repositories.
+func (svc *APIService) RequestIDMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			req := ctx.Request()
+
+			requestID := req.Header.Get(requestIDHeader)
+			if requestID == "" {
+				requestID = newRequestID()
+			}
+			ctx.Response().Header().Set(requestIDHeader, requestID)
+
+			fields := logrus.Fields{"request_id": requestID}
+			if span := trace.SpanFromContext(req.Context()).SpanContext(); span.IsValid() {
+				fields["trace_id"] = span.TraceID().String()
+			}
+			ctx.SetRequest(req.WithContext(logging.WithLogger(req.Context(), svc.log.WithFields(fields))))
+			return next(ctx)
+		}
+	}
+}
+
+// AuthMiddleware resolves the trusted proxy header or the bearer token of a
+// request into the identity services authorize against. In token mode
+// requests without either act as nobody and may only read; in open mode they
+// carry no identity and may claim any author, as before.
+func (svc *APIService) AuthMiddleware(authService service.AuthService) echo.MiddlewareFunc {
+	const scheme = "bearer "
+	trustedHeader := svc.cfg.Auth.TrustedHeader
+	required := svc.cfg.Auth.Mode == "token"
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			req := ctx.Request()
+			identity := &auth.Identity{}
+			reqCtx := req.Context()
+
+			if nickname := req.Header.Get(trustedHeader); trustedHeader != "" && nickname != "" {
+				identity.Nickname = nickname
+				reqCtx = logging.WithLogger(reqCtx, logging.FromContext(reqCtx, svc.log).WithField("user", nickname))
+			} else if header := req.Header.Get(echo.HeaderAuthorization); header != "" {
+				if len(header) <= len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) {
+					return domain.Unauthorized("Unsupported authorization scheme")
+				}
+				nickname, err := authService.Authenticate(reqCtx, strings.TrimSpace(header[len(scheme):]))
+				if err != nil {
+					return err
+				}
+				identity.Nickname = nickname
+				reqCtx = logging.WithLogger(reqCtx, logging.FromContext(reqCtx, svc.log).WithField("user", nickname))
+			}
+
+			if identity.Nickname == "" && !required {
+				return next(ctx)
+			}
+			ctx.SetRequest(req.WithContext(auth.WithIdentity(reqCtx, identity)))
+			return next(ctx)
+		}
+	}
+}
+
+// RateLimitMiddleware charges every request against budget of the client IP
+// and answers 429 once it runs dry.
+func RateLimitMiddleware(limiter ratelimit.Limiter, budget ratelimit.Budget) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			ip := ctx.RealIP()
+			if wait := limiter.Allow(budget, "ip:"+ip); wait > 0 {
+				return domain.RateLimited(wait, "Too many %s from %s, retry in %s", budget, ip, wait.Round(time.Second))
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// TimeoutMiddleware puts a deadline on the request context. Services and pgx
+// observe it, so an expired or disconnected request cancels its queries.
+func TimeoutMiddleware(timeout time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if timeout <= 0 {
+			return next
+		}
+		return func(ctx echo.Context) error {
+			req := ctx.Request()
+			timeoutCtx, cancel := context.WithTimeout(req.Context(), timeout)
+			defer cancel()
+			ctx.SetRequest(req.WithContext(timeoutCtx))
+			return next(ctx)
+		}
+	}
+}
+
 func (svc *APIService) LoggingMiddleware() echo.MiddlewareFunc {
+	cfg := svc.cfg.Log.Access
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) (err error) {
 			req := ctx.Request()
 			res := ctx.Response()
 
-			var bodyBytes []byte
-
-			log := svc.log.WithFields(logrus.Fields{
-				"user_agent":  req.UserAgent(),
-				"host":        req.Host,
-				"uri":         req.RequestURI,
-				"http_method": req.Method,
-			})
+			var reqBody []byte
+			var reqRest *countingReader
+			var recorder *bodyRecorder
+			if cfg.CaptureBodies != "none" && !svc.isStream(ctx) {
+				// Only as much of the body is read up front as can be logged;
+				// the handler reads the rest through.
+				if req.Body != nil {
+					reqBody, _ = io.ReadAll(io.LimitReader(req.Body, int64(cfg.BodyMaxBytes)+1))
+					reqRest = &countingReader{Reader: req.Body}
+					req.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(reqBody), reqRest), Closer: req.Body}
+				}
+				recorder = &bodyRecorder{ResponseWriter: res.Writer, limit: cfg.BodyMaxBytes}
+				res.Writer = recorder
+			}
 
 			start := time.Now()
 			if err = next(ctx); err != nil {
 				ctx.Error(err)
 			}
-
 			stop := time.Now()
-			if res.Status >= 400 {
-				if len(bodyBytes) > 4096 {
-					bodyBytes = bodyBytes[:4096]
-				}
-				log = log.WithFields(logrus.Fields{
-					"body": string(bodyBytes),
-				})
+
+			failed := res.Status >= 400
+			if !failed && mathrand.Float64() >= cfg.SuccessSampleRate {
+				return nil
 			}
 
-			log = log.WithFields(logrus.Fields{
+			log := logging.FromContext(req.Context(), svc.log).WithFields(logrus.Fields{
+				"user_agent":     req.UserAgent(),
+				"host":           req.Host,
+				"remote_ip":      ctx.RealIP(),
+				"uri":            req.RequestURI,
+				"route":          ctx.Path(),
+				"http_method":    req.Method,
 				"execution_time": stop.Sub(start).String(),
 				"status":         res.Status,
+				"bytes_in":       int64(len(reqBody)) + reqRest.count(),
+				"bytes_out":      res.Size,
 			})
 
-			if res.Status >= 400 {
+			if recorder != nil && (failed || cfg.CaptureBodies == "all") {
+				log = log.WithFields(logrus.Fields{
+					"request_body":  sanitizeBody(reqBody, cfg.BodyMaxBytes, cfg.RedactEmails),
+					"response_body": sanitizeBody(recorder.body.Bytes(), cfg.BodyMaxBytes, cfg.RedactEmails),
+				})
+			}
+
+			if failed && err != nil {
 				log.Infof("[error]: %v", err)
+			} else if failed {
+				log.Info("[error]")
 			} else {
 				log.Info("[success]")
 			}
@@ -51,3 +219,37 @@ func (svc *APIService) LoggingMiddleware() echo.MiddlewareFunc {
 		}
 	}
 }
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.Reader.Read(b)
+	r.n += int64(n)
+	return n, err
+}
+
+func (r *countingReader) count() int64 {
+	if r == nil {
+		return 0
+	}
+	return r.n
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func sanitizeBody(body []byte, limit int, redact bool) string {
+	if len(body) > limit {
+		body = body[:limit]
+	}
+	if redact {
+		body = emailRe.ReplaceAll(body, []byte("[redacted]"))
+	}
+	return string(body)
+}
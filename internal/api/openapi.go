@@ -0,0 +1,125 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/logging"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	"github.com/rinatkh/db_forum/internal/openapi"
+)
+
+// responseBuffer holds back a response until it has been validated.
+type responseBuffer struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+func (b *responseBuffer) flush() error {
+	if b.status == 0 {
+		return nil
+	}
+	b.ResponseWriter.WriteHeader(b.status)
+	_, err := b.ResponseWriter.Write(b.body.Bytes())
+	return err
+}
+
+// OpenAPIMiddleware checks requests and responses of the routes described by
+// doc. In log mode mismatches are logged; in fail mode a mismatching request
+// is refused with 400 and a mismatching response is replaced with a 500, so
+// that drift breaks tests. Responses are buffered, so this is meant for
+// debugging and test runs only.
+func (svc *APIService) OpenAPIMiddleware(doc *openapi.Spec, mode string) echo.MiddlewareFunc {
+	fail := mode == "fail"
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			req := ctx.Request()
+			op, ok := doc.Operation(req.Method, ctx.Path())
+			if !ok {
+				return next(ctx)
+			}
+			log := logging.FromContext(req.Context(), svc.log)
+
+			var body []byte
+			if req.Body != nil {
+				var err error
+				if body, err = io.ReadAll(req.Body); err != nil {
+					return err
+				}
+				req.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			if mismatches := op.ValidateRequest(ctx.Param, req.URL.Query(), body); len(mismatches) > 0 {
+				log.WithField("mismatches", mismatches).Warnf("request %s %s does not match the API spec", req.Method, ctx.Path())
+				if fail {
+					return domain.InvalidFields(mismatches...)
+				}
+			}
+
+			if svc.isStream(ctx) {
+				return next(ctx)
+			}
+
+			res := ctx.Response()
+			buffer := &responseBuffer{ResponseWriter: res.Writer}
+			res.Writer = buffer
+			err := next(ctx)
+			if err != nil {
+				ctx.Error(err)
+			}
+			res.Writer = buffer.ResponseWriter
+
+			// Not modified and HEAD responses carry no body, cursor mode
+			// listings answer with pages the spec can't describe and sparse
+			// fieldsets may leave out required properties.
+			if buffer.status == http.StatusNotModified || req.Method == http.MethodHead || req.URL.Query().Has("cursor") || req.URL.Query().Has("fields") {
+				if flushErr := buffer.flush(); flushErr != nil {
+					log.Errorf("unable to write response: %s", flushErr)
+				}
+				return err
+			}
+
+			mismatches := op.ValidateResponse(buffer.status, buffer.body.Bytes())
+			if len(mismatches) > 0 {
+				log.WithField("mismatches", mismatches).Warnf("response %d to %s %s does not match the API spec", buffer.status, req.Method, ctx.Path())
+			}
+			if len(mismatches) == 0 || !fail {
+				if flushErr := buffer.flush(); flushErr != nil {
+					log.Errorf("unable to write response: %s", flushErr)
+				}
+				return err
+			}
+
+			response := dto.ErrorResponse{Message: "response does not match the API spec"}
+			for _, mismatch := range mismatches {
+				response.Errors = append(response.Errors, dto.FieldError{Field: mismatch.Field, Message: mismatch.Message})
+			}
+			header := res.Header()
+			for _, name := range []string{echo.HeaderContentLength, echo.HeaderContentEncoding, echo.HeaderLastModified, "ETag"} {
+				header.Del(name)
+			}
+			header.Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+			res.Status = http.StatusInternalServerError
+			res.Writer.WriteHeader(http.StatusInternalServerError)
+			if encodeErr := json.NewEncoder(res.Writer).Encode(response); encodeErr != nil {
+				log.Errorf("unable to write response: %s", encodeErr)
+			}
+			return err
+		}
+	}
+}
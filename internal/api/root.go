@@ -4,74 +4,372 @@ import (
 	"context"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
+	forum "github.com/rinatkh/db_forum"
 	controllers "github.com/rinatkh/db_forum/internal/api/contollers"
+	"github.com/rinatkh/db_forum/internal/auth"
+	"github.com/rinatkh/db_forum/internal/codec"
+	"github.com/rinatkh/db_forum/internal/config"
 	"github.com/rinatkh/db_forum/internal/db"
+	"github.com/rinatkh/db_forum/internal/events"
+	"github.com/rinatkh/db_forum/internal/graphql"
+	"github.com/rinatkh/db_forum/internal/logging"
+	"github.com/rinatkh/db_forum/internal/metrics"
+	"github.com/rinatkh/db_forum/internal/migrate"
+	"github.com/rinatkh/db_forum/internal/openapi"
+	"github.com/rinatkh/db_forum/internal/ratelimit"
+	"github.com/rinatkh/db_forum/internal/rpc"
 	"github.com/rinatkh/db_forum/internal/service"
+	"github.com/rinatkh/db_forum/internal/tracing"
 	"github.com/sirupsen/logrus"
+	"net"
+	"net/http"
+	"strings"
+	"time"
 )
 
+// v2Prefix is where the v2 API is served; the rest of /api is v1, frozen to
+// swagger.yaml.
+const v2Prefix = "/api/v2"
+
 type APIService struct {
 	log    *logrus.Entry
 	router *echo.Echo
+	cfg    *config.Config
+	health *controllers.HealthController
+	rpc    *rpc.Server
+	broker events.Broker
+	// listen feeds the broker from Postgres notifications, if configured.
+	listen func()
+	stop   context.CancelFunc
+	// streams are the routes answering with long-lived event streams, which
+	// must reach the client unbuffered.
+	streams map[string]bool
 }
 
 func (svc *APIService) Serve() {
-	listenAddr := "0.0.0.0:5000"
-	svc.log.Fatal(svc.router.Start(listenAddr))
+	if svc.listen != nil {
+		go svc.listen()
+	}
+	if svc.rpc != nil {
+		lis, err := net.Listen("tcp", svc.cfg.GRPC.ListenAddr)
+		if err != nil {
+			svc.log.Fatal(err)
+		}
+		go func() {
+			if err := svc.rpc.Serve(lis); err != nil {
+				svc.log.Fatal(err)
+			}
+		}()
+	}
+
+	server := &http.Server{
+		Addr:         svc.cfg.Server.ListenAddr,
+		ReadTimeout:  svc.cfg.Server.ReadTimeout,
+		WriteTimeout: svc.cfg.Server.WriteTimeout,
+		IdleTimeout:  svc.cfg.Server.IdleTimeout,
+	}
+	if err := svc.router.StartServer(server); err != nil && err != http.ErrServerClosed {
+		svc.log.Fatal(err)
+	}
 }
 
+// Shutdown fails readiness first and keeps serving for the configured drain
+// delay, so load balancers stop routing here before connections are refused.
 func (svc *APIService) Shutdown(ctx context.Context) error {
+	svc.health.SetDraining()
+	if delay := svc.cfg.Server.DrainDelay; delay > 0 {
+		svc.log.Infof("draining for %s before shutdown", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+
+	// Event streams never become idle, so they are ended before the server
+	// waits for its connections.
+	if svc.stop != nil {
+		svc.stop()
+	}
+	svc.broker.Close()
+
 	if err := svc.router.Shutdown(ctx); err != nil {
 		svc.log.Fatal(err)
 	}
+	if svc.rpc != nil {
+		svc.rpc.Shutdown(ctx)
+	}
 	return nil
 }
 
-func NewAPIService(log *logrus.Entry, dbConn *pgxpool.Pool) (*APIService, error) {
+func NewAPIService(log *logrus.Entry, dbConn *pgxpool.Pool, cfg *config.Config) (*APIService, error) {
 	svc := &APIService{
-		log:    log,
-		router: echo.New(),
+		log:     log,
+		router:  echo.New(),
+		cfg:     cfg,
+		broker:  events.NewBroker(cfg.Events.Buffer),
+		streams: make(map[string]bool),
 	}
 
+	svc.router.HTTPErrorHandler = svc.HTTPErrorHandler
+	svc.router.IPExtractor = ipExtractor(cfg.Server.TrustedProxies)
+
 	repository, err := db.NewRepository(dbConn)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	//svc.router.Validator = NewValidator()
-	//svc.router.Binder = NewBinder()
-	//svc.router.Use(svc.LoggingMiddleware())
+	var repositoryHooks []db.Hook
+	if cfg.Tracing.Enabled {
+		repositoryHooks = append(repositoryHooks, tracing.RepositoryHook{})
+		svc.router.Use(tracing.Middleware())
+	}
+	svc.router.Use(svc.RequestIDMiddleware())
+	encodings, err := newEncodings(cfg.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	svc.router.Use(svc.EncodingMiddleware(encodings))
+	repositoryHooks = append(repositoryHooks, logging.NewRepositoryHook(log))
+	if cfg.Features.Metrics {
+		m := metrics.New(dbConn)
+		repositoryHooks = append(repositoryHooks, m)
+		svc.router.Use(m.Middleware())
+		svc.router.GET("/metrics", echo.WrapHandler(m.Handler()))
+	}
+	repository = db.WithHooks(repository, repositoryHooks...)
 
-	registry := service.NewRegistry(log, repository)
+	jsonCodec, err := codec.New(cfg.Server.JSONCodec)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("encoding JSON with %s", jsonCodec.Name())
+	svc.router.JSONSerializer = NewJSONSerializer(jsonCodec)
+	svc.router.Binder = NewBinder(jsonCodec)
+	if cfg.Features.Validation {
+		svc.router.Validator = NewValidator()
+	}
+	if cfg.Features.RequestLogging {
+		svc.router.Use(svc.LoggingMiddleware())
+	}
+
+	migrator, err := migrate.NewMigrator(log, dbConn)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := auth.NewSigner(cfg.Auth.Secret, cfg.Auth.TokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	limiter := newLimiter(cfg.RateLimit)
+	flood := service.FloodControl{Limiter: limiter, DuplicateWindow: cfg.RateLimit.DuplicateWindow}
+	publisher := events.Discard
+	if cfg.Events.Source == "postgres" {
+		listener := events.NewListener(log, dbConn.Config().ConnConfig, repository.PostsRepository, svc.broker)
+		var listenCtx context.Context
+		listenCtx, svc.stop = context.WithCancel(context.Background())
+		svc.listen = func() { listener.Run(listenCtx) }
+	} else {
+		publisher = svc.broker
+	}
+	registry := service.NewRegistry(log, repository, signer, flood, publisher)
+	if cfg.Tracing.Enabled {
+		registry = service.WithTracing(registry)
+	}
+	if cfg.Auth.Mode == "token" && cfg.Auth.Secret == "" {
+		log.Warn("auth.secret is not set, login tokens will not survive a restart")
+	}
+	svc.router.Use(svc.AuthMiddleware(registry.AuthService))
+	if cfg.OpenAPI.Validation != "off" {
+		doc, err := openapi.Load(forum.Swagger)
+		if err != nil {
+			return nil, err
+		}
+		svc.router.Use(svc.OpenAPIMiddleware(doc, cfg.OpenAPI.Validation))
+	}
 	userCtrl := controllers.NewUserController(log, registry)
 	forumCtrl := controllers.NewForumController(log, registry)
 	threadCtrl := controllers.NewThreadController(log, registry)
 	postCtrl := controllers.NewPostController(log, registry)
-	serviceCtrl := controllers.NewServiceController(log, repository)
+	authCtrl := controllers.NewAuthController(log, registry)
+	streamCtrl := controllers.NewStreamController(log, registry, svc.broker, cfg.Events.Keepalive)
+	serviceCtrl := controllers.NewServiceController(log, repository, registry.Policy)
+	svc.health = controllers.NewHealthController(log, dbConn, migrator)
+
+	svc.router.GET("/healthz", svc.health.Liveness)
+	svc.router.GET("/readyz", svc.health.Readiness)
 
 	api := svc.router.Group("/api")
 
-	api.POST("/forum/create", forumCtrl.CreateForum)
-	api.GET("/forum/:slug/details", forumCtrl.GetForum)
-	api.POST("/forum/:slug/create", threadCtrl.CreateThread)
-	api.GET("/forum/:slug/users", forumCtrl.GetForumUsers)
-	api.GET("/forum/:slug/threads", forumCtrl.GetForumThreads)
+	if cfg.OpenAPI.Docs {
+		docsCtrl := controllers.NewDocsController(log, forum.Swagger)
+		api.GET("/swagger.yaml", docsCtrl.Spec)
+		api.GET("/docs", docsCtrl.UI)
+	}
 
-	api.GET("/post/:id/details", postCtrl.GetPostDetails)
-	api.POST("/post/:id/details", postCtrl.UpdatePost)
+	timeouts := cfg.Server.RequestTimeouts
+	read := TimeoutMiddleware(timeouts.Read)
+	write := TimeoutMiddleware(timeouts.Write)
+	bulk := TimeoutMiddleware(timeouts.Bulk)
+	idempotent := svc.IdempotencyMiddleware(repository.IdempotencyRepository, cfg.Server.IdempotencyTTL)
+	threadsLimit := RateLimitMiddleware(limiter, ratelimit.Threads)
+	postsLimit := RateLimitMiddleware(limiter, ratelimit.Posts)
+	votesLimit := RateLimitMiddleware(limiter, ratelimit.Votes)
 
-	api.POST("/service/clear", serviceCtrl.Clear)
-	api.GET("/service/status", serviceCtrl.Status)
+	api.POST("/auth/login", authCtrl.Login, write)
 
-	api.POST("/thread/:slug_or_id/create", postCtrl.CreatePosts)
-	api.GET("/thread/:slug_or_id/details", threadCtrl.GetThread)
-	api.POST("/thread/:slug_or_id/details", threadCtrl.EditThread)
-	api.GET("/thread/:slug_or_id/posts", postCtrl.GetPosts)
-	api.POST("/thread/:slug_or_id/vote", threadCtrl.CountVote)
+	api.POST("/forum/create", forumCtrl.CreateForum, idempotent, write)
+	api.GET("/forum/:slug/details", forumCtrl.GetForum, read)
+	api.POST("/forum/:slug/create", threadCtrl.CreateThread, idempotent, threadsLimit, write)
+	api.GET("/forum/:slug/users", forumCtrl.GetForumUsers, read)
+	api.GET("/forum/:slug/threads", forumCtrl.GetForumThreads, read)
+	api.GET("/forum/:slug/moderators", forumCtrl.GetModerators, read)
+	api.POST("/forum/:slug/moderators", forumCtrl.AddModerator, write)
+	api.DELETE("/forum/:slug/moderators/:nickname", forumCtrl.RemoveModerator, write)
 
-	api.POST("/user/:nickname/create", userCtrl.CreateUser)
-	api.GET("/user/:nickname/profile", userCtrl.GetUserProfile)
-	api.POST("/user/:nickname/profile", userCtrl.EditUserProfile)
+	api.GET("/post/:id/details", postCtrl.GetPostDetails, read)
+	api.POST("/post/:id/details", postCtrl.UpdatePost, write)
+
+	api.POST("/service/clear", serviceCtrl.Clear, write)
+	api.GET("/service/status", serviceCtrl.Status, read)
+
+	api.POST("/thread/:slug_or_id/create", postCtrl.CreatePosts, idempotent, postsLimit, bulk)
+	api.GET("/thread/:slug_or_id/details", threadCtrl.GetThread, read)
+	api.POST("/thread/:slug_or_id/details", threadCtrl.EditThread, write)
+	api.GET("/thread/:slug_or_id/posts", postCtrl.GetPosts, read)
+	api.POST("/thread/:slug_or_id/vote", threadCtrl.CountVote, idempotent, votesLimit, write)
+	api.POST("/thread/:slug_or_id/lock", threadCtrl.LockThread, write)
+	for _, route := range []*echo.Route{
+		api.GET("/thread/:slug_or_id/stream", streamCtrl.Events),
+		api.GET("/thread/:slug_or_id/ws", streamCtrl.WebSocket),
+	} {
+		svc.streams[route.Path] = true
+	}
+
+	api.POST("/user/:nickname/create", userCtrl.CreateUser, idempotent, write)
+	api.GET("/user/:nickname/profile", userCtrl.GetUserProfile, read)
+	api.POST("/user/:nickname/profile", userCtrl.EditUserProfile, write)
+	api.POST("/user/:nickname/password", authCtrl.SetPassword, write)
+	api.POST("/user/:nickname/ban", userCtrl.BanUser, write)
+
+	if cfg.GraphQL.Enabled {
+		executor, err := graphql.NewExecutor(log, registry, svc.router.Validator, graphql.Options{
+			MaxDepth:       cfg.GraphQL.MaxDepth,
+			MaxCost:        cfg.GraphQL.MaxCost,
+			MaxParallelism: cfg.GraphQL.MaxParallelism,
+			Introspection:  cfg.GraphQL.Introspection,
+			Tracing:        cfg.Tracing.Enabled,
+		})
+		if err != nil {
+			return nil, err
+		}
+		graphqlCtrl := controllers.NewGraphQLController(log, executor)
+		api.POST("/graphql", graphqlCtrl.Query, idempotent, write)
+	}
+
+	if cfg.Features.APIv2 {
+		v2Ctrl := controllers.NewV2Controller(log, registry)
+		v2 := svc.router.Group(v2Prefix)
+
+		v2.POST("/forum/create", v2Ctrl.CreateForum, idempotent, write)
+		v2.GET("/forum/:slug/details", v2Ctrl.GetForum, read)
+		v2.POST("/forum/:slug/create", v2Ctrl.CreateThread, idempotent, threadsLimit, write)
+		v2.GET("/forum/:slug/users", v2Ctrl.GetForumUsers, read)
+		v2.GET("/forum/:slug/threads", v2Ctrl.GetForumThreads, read)
+
+		v2.GET("/post/:id/details", v2Ctrl.GetPost, read)
+		v2.POST("/post/:id/details", v2Ctrl.EditPost, write)
+
+		v2.POST("/thread/:slug_or_id/create", v2Ctrl.CreatePosts, idempotent, postsLimit, bulk)
+		v2.GET("/thread/:slug_or_id/details", v2Ctrl.GetThread, read)
+		v2.POST("/thread/:slug_or_id/details", v2Ctrl.EditThread, write)
+		v2.GET("/thread/:slug_or_id/posts", v2Ctrl.GetThreadPosts, read)
+		v2.POST("/thread/:slug_or_id/vote", v2Ctrl.Vote, idempotent, votesLimit, write)
+
+		v2.POST("/user/:nickname/create", v2Ctrl.CreateUser, idempotent, write)
+		v2.GET("/user/:nickname/profile", v2Ctrl.GetUser, read)
+		v2.POST("/user/:nickname/profile", v2Ctrl.EditUser, write)
+	}
+
+	if cfg.Batch.Enabled {
+		excluded := map[string]string{"/api/batch": "batches can't be nested"}
+		for route := range svc.streams {
+			excluded[route] = "event streams can't be batched"
+		}
+		if cfg.GraphQL.Enabled {
+			// GraphQL resolves fields concurrently, which a shared
+			// transaction does not allow.
+			excluded["/api/graphql"] = ""
+		}
+		batchCtrl := controllers.NewBatchController(log, repository.Transactor, cfg.Batch.MaxRequests, excluded, cfg.Auth.TrustedHeader)
+		api.POST("/batch", batchCtrl.Batch, idempotent, bulk)
+	}
+
+	if cfg.GRPC.Enabled {
+		svc.rpc = rpc.NewServer(log, registry, svc.router.Validator, rpc.Options{
+			Auth:          cfg.Auth.Mode == "token",
+			TrustedHeader: strings.ToLower(cfg.Auth.TrustedHeader),
+			Limiter:       limiter,
+			ReadTimeout:   timeouts.Read,
+			WriteTimeout:  timeouts.Write,
+			BulkTimeout:   timeouts.Bulk,
+			Reflection:    cfg.GRPC.Reflection,
+		})
+	}
 
 	return svc, nil
 }
+
+// ipExtractor takes the client IP from X-Forwarded-For when the request came
+// through one of proxies, which config validation checked to be CIDR ranges,
+// and from the peer address otherwise. Clients can't choose their IP either
+// way, which rate limits depend on.
+func ipExtractor(proxies []string) echo.IPExtractor {
+	if len(proxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range proxies {
+		_, ipRange, _ := net.ParseCIDR(proxy)
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// newLimiter builds the write limiter from cfg; it limits nothing when rate
+// limiting is disabled.
+func newLimiter(cfg config.RateLimitConfig) ratelimit.Limiter {
+	if !cfg.Enabled {
+		return ratelimit.New(nil)
+	}
+	return ratelimit.New(map[ratelimit.Budget]ratelimit.Limit{
+		ratelimit.Threads: {PerMinute: cfg.Threads.PerMinute, Burst: cfg.Threads.Burst},
+		ratelimit.Posts:   {PerMinute: cfg.Posts.PerMinute, Burst: cfg.Posts.Burst},
+		ratelimit.Votes:   {PerMinute: cfg.Votes.PerMinute, Burst: cfg.Votes.Burst},
+	})
+}
+
+// newEncodings builds the negotiated representations from cfg. Compressed
+// request bodies are accepted in every known coding, enabled or not.
+func newEncodings(cfg config.EncodingConfig) (Encodings, error) {
+	gzip, err := codec.NewGzip(cfg.Gzip.Level)
+	if err != nil {
+		return Encodings{}, err
+	}
+	zstd, err := codec.NewZstd(cfg.Zstd.Level)
+	if err != nil {
+		return Encodings{}, err
+	}
+	encodings := Encodings{
+		MsgPack:        cfg.MsgPack,
+		Decompressions: []codec.Compression{gzip, zstd},
+		MaxRequestSize: cfg.MaxRequestSize,
+	}
+	if cfg.Zstd.Enabled {
+		encodings.Compressions = append(encodings.Compressions, Compression{Compression: zstd, MinSize: cfg.Zstd.MinSize})
+	}
+	if cfg.Gzip.Enabled {
+		encodings.Compressions = append(encodings.Compressions, Compression{Compression: gzip, MinSize: cfg.Gzip.MinSize})
+	}
+	return encodings, nil
+}
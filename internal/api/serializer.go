@@ -0,0 +1,50 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/codec"
+)
+
+// jsonSerializer renders and reads JSON with the configured codec. It is a
+// codec.Codec itself, so that handlers encoding bodies on their own can use
+// the same one.
+type jsonSerializer struct {
+	codec.Codec
+}
+
+func (s *jsonSerializer) Serialize(ctx echo.Context, i interface{}, indent string) error {
+	body, err := s.Marshal(i)
+	if err != nil {
+		return err
+	}
+	if indent != "" {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "", indent); err != nil {
+			return err
+		}
+		body = indented.Bytes()
+	}
+	// Keep the trailing newline of the encoding/json encoder echo used before.
+	body = append(body, '\n')
+	_, err = ctx.Response().Write(body)
+	return err
+}
+
+func (s *jsonSerializer) Deserialize(ctx echo.Context, i interface{}) error {
+	body, err := io.ReadAll(ctx.Request().Body)
+	if err != nil {
+		return err
+	}
+	if err := s.Unmarshal(body, i); err != nil {
+		return bodyError(err)
+	}
+	return nil
+}
+
+func NewJSONSerializer(c codec.Codec) echo.JSONSerializer {
+	return &jsonSerializer{Codec: c}
+}
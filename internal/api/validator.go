@@ -3,62 +3,312 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/labstack/gommon/log"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"github.com/rinatkh/db_forum/internal/codec"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/model/core"
 )
 
+var (
+	nicknameRe = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+	// slugRe follows the swagger pattern and additionally rejects purely
+	// numeric slugs, which would be taken for thread ids in slug_or_id.
+	slugRe = regexp.MustCompile(`^[A-Za-z0-9_-]*[A-Za-z_-][A-Za-z0-9_-]*$`)
+
+	relatedValues = map[string]bool{"user": true, "thread": true, "forum": true}
+	embedValues   = map[string]bool{"author": true, "forum": true}
+	// fieldValues are the JSON fields a fields= parameter may select, by the
+	// entity named in the tag parameter.
+	fieldValues = map[string][]string{
+		"thread": jsonFields(core.Thread{}),
+		"post":   jsonFields(core.Post{}),
+		"user":   jsonFields(core.User{}),
+	}
+)
+
+// jsonFields lists the keys value is encoded with, in field order.
+func jsonFields(value interface{}) []string {
+	typ := reflect.TypeOf(value)
+	names := make([]string, 0, typ.NumField())
+	for n := 0; n < typ.NumField(); n++ {
+		name := strings.SplitN(typ.Field(n).Tag.Get("json"), ",", 2)[0]
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 type validatorImpl struct {
 	validator *validator.Validate
 }
 
+// Validate checks a request struct, or every element of a request slice,
+// against its validate tags and reports the failures per field.
 func (v *validatorImpl) Validate(i interface{}) error {
-	if err := v.validator.Struct(i); err != nil {
+	var err error
+	if kind := reflect.Indirect(reflect.ValueOf(i)).Kind(); kind == reflect.Slice || kind == reflect.Array {
+		err = v.validator.Var(i, "dive,required")
+	} else {
+		err = v.validator.Struct(i)
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
 		return err
 	}
-	return nil
+	fields := make([]domain.FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields = append(fields, domain.FieldError{Field: fieldName(fieldErr), Message: fieldMessage(fieldErr)})
+	}
+	return domain.InvalidFields(fields...)
+}
+
+// fieldName strips the name of the validated type from the namespace, leaving
+// e.g. "email" or "[2].author".
+func fieldName(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if i := strings.IndexAny(namespace, ".["); i >= 0 && namespace[i] == '.' {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func fieldMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "nickname":
+		return "may contain only latin letters, digits, '_' and '.'"
+	case "slug":
+		return "may contain only latin letters, digits, '_' and '-' and must not be a number"
+	case "rfc3339":
+		return "must be an RFC3339 timestamp"
+	case "related":
+		return "must be a comma separated list of user, thread and forum"
+	case "embed":
+		return "must be a comma separated list of author and forum"
+	case "fields":
+		return "must be a comma separated list of " + strings.Join(fieldValues[fieldErr.Param()], ", ")
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	case "startswith":
+		return "must start with " + fieldErr.Param()
+	case "min":
+		return "must be at least " + fieldErr.Param()
+	case "max":
+		return "must be at most " + fieldErr.Param()
+	default:
+		return fmt.Sprintf("failed the %q check", fieldErr.Tag())
+	}
+}
+
+// tagName names fields the way clients send them: by their JSON key, query
+// parameter or path parameter.
+func tagName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "query", "param"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
 }
 
 func NewValidator() echo.Validator {
-	return &validatorImpl{validator: validator.New()}
+	v := validator.New()
+	v.RegisterTagNameFunc(tagName)
+	_ = v.RegisterValidation("nickname", func(fl validator.FieldLevel) bool {
+		return nicknameRe.MatchString(fl.Field().String())
+	})
+	_ = v.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+		return slugRe.MatchString(fl.Field().String())
+	})
+	_ = v.RegisterValidation("rfc3339", func(fl validator.FieldLevel) bool {
+		_, err := time.Parse(time.RFC3339, fl.Field().String())
+		return err == nil
+	})
+	_ = v.RegisterValidation("related", func(fl validator.FieldLevel) bool {
+		for _, value := range strings.Split(fl.Field().String(), ",") {
+			if !relatedValues[value] {
+				return false
+			}
+		}
+		return true
+	})
+	_ = v.RegisterValidation("embed", func(fl validator.FieldLevel) bool {
+		for _, value := range strings.Split(fl.Field().String(), ",") {
+			if !embedValues[value] {
+				return false
+			}
+		}
+		return true
+	})
+	_ = v.RegisterValidation("fields", func(fl validator.FieldLevel) bool {
+		allowed := fieldValues[fl.Param()]
+		for _, value := range strings.Split(fl.Field().String(), ",") {
+			if !contains(allowed, value) {
+				return false
+			}
+		}
+		return true
+	})
+	return &validatorImpl{validator: v}
 }
 
-type binderImpl struct{}
+// binderImpl fills a request from the JSON body, the query string and the
+// path parameters, then validates it if a validator is registered. Malformed
+// input is reported as a validation error instead of becoming zero values.
+type binderImpl struct {
+	codec codec.Codec
+}
 
 func (b *binderImpl) Bind(i interface{}, ctx echo.Context) error {
-	db := new(echo.DefaultBinder)
-
 	buf := new(bytes.Buffer)
-	_, err := buf.ReadFrom(ctx.Request().Body)
-	if err != nil {
-		log.Errorf("Unmarshal error: %s", err)
+	if _, err := buf.ReadFrom(ctx.Request().Body); err != nil {
+		return err
+	}
+	if buf.Len() > 0 {
+		if err := b.codec.Unmarshal(buf.Bytes(), i); err != nil {
+			return bodyError(err)
+		}
+	}
+
+	var fields []domain.FieldError
+	fields = append(fields, bindValues(i, ctx.QueryParams(), "query")...)
+	names, values := ctx.ParamNames(), ctx.ParamValues()
+	params := make(map[string][]string, len(names))
+	for n, name := range names {
+		if n < len(values) {
+			params[name] = []string{values[n]}
+		}
+	}
+	fields = append(fields, bindValues(i, params, "param")...)
+
+	err := ctx.Validate(i)
+	if errors.Is(err, echo.ErrValidatorNotRegistered) {
+		err = nil
+	}
+	if len(fields) == 0 {
 		return err
 	}
-	err = json.Unmarshal(buf.Bytes(), i)
-	if err != nil {
-		log.Errorf("Unmarshal error: %s", err)
+	// Fields that failed to parse were left zero, so only report the checks
+	// of the remaining ones.
+	if invalid, ok := domain.As(err); ok {
+		unparsed := make(map[string]bool, len(fields))
+		for _, field := range fields {
+			unparsed[field.Field] = true
+		}
+		for _, field := range invalid.Fields {
+			if !unparsed[field.Field] {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return domain.InvalidFields(fields...)
+}
+
+func bodyError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return domain.InvalidFields(domain.FieldError{Field: typeErr.Field, Message: "must be " + jsonTypeName(typeErr.Type)})
 	}
-	if err := db.BindQueryParams(ctx, i); err != nil {
-		return fmt.Errorf("%v", err)
+	return domain.InvalidFields(domain.FieldError{Field: "body", Message: "malformed JSON: " + err.Error()})
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
 	}
+}
 
-	if err := db.BindPathParams(ctx, i); err != nil {
-		return fmt.Errorf("%v", err)
+// bindValues sets the fields of the struct pointed to by i that carry the
+// given tag; empty values count as absent. Non-struct requests, e.g. the post
+// list body, are left alone.
+func bindValues(i interface{}, values map[string][]string, tag string) []domain.FieldError {
+	val := reflect.Indirect(reflect.ValueOf(i))
+	if val.Kind() != reflect.Struct || len(values) == 0 {
+		return nil
 	}
+	typ := val.Type()
 
-	if err := db.BindHeaders(ctx, i); err != nil {
-		return fmt.Errorf("%v", err)
+	var fields []domain.FieldError
+	for n := 0; n < typ.NumField(); n++ {
+		name := typ.Field(n).Tag.Get(tag)
+		raw, ok := values[name]
+		if name == "" || !ok || len(raw) == 0 || raw[0] == "" {
+			continue
+		}
+		if err := setValue(val.Field(n), raw[0]); err != nil {
+			fields = append(fields, domain.FieldError{Field: name, Message: err.Error()})
+		}
 	}
+	return fields
+}
 
-	if err := ctx.Validate(i); err != nil {
-		return fmt.Errorf("%v", err)
+func setValue(field reflect.Value, raw string) error {
+	if field.Kind() == reflect.Ptr {
+		value := reflect.New(field.Type().Elem())
+		if err := setValue(value.Elem(), raw); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
 	}
 
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		field.SetInt(parsed)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("must be a boolean")
+		}
+		field.SetBool(parsed)
+	default:
+		return fmt.Errorf("unsupported parameter type %s", field.Type())
+	}
 	return nil
 }
 
-func NewBinder() echo.Binder {
-	return &binderImpl{}
+func NewBinder(c codec.Codec) echo.Binder {
+	return &binderImpl{codec: c}
 }
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/rinatkh/db_forum/internal/domain"
+)
+
+type identityKey struct{}
+
+// Identity is the user a request acts as. Nickname is empty for requests
+// that carry no credentials. System marks internal callers such as the admin
+// commands, which act as no user and are not restricted.
+type Identity struct {
+	Nickname string
+	System   bool
+}
+
+// WithIdentity marks ctx as belonging to an authenticated API request.
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// AsSystem marks ctx as belonging to an internal caller.
+func AsSystem(ctx context.Context) context.Context {
+	return WithIdentity(ctx, &Identity{System: true})
+}
+
+// FromContext returns the identity of the request, if authentication is
+// enforced for it.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok
+}
+
+// Authorize checks that the request may act on behalf of nickname. Contexts
+// without an identity come from anonymous requests in open mode and, like
+// internal callers, are not restricted.
+func Authorize(ctx context.Context, nickname string) error {
+	identity, ok := FromContext(ctx)
+	if !ok || identity.System {
+		return nil
+	}
+	if identity.Nickname == "" {
+		return domain.Unauthorized("Authentication required to act as %s", nickname)
+	}
+	if !strings.EqualFold(identity.Nickname, nickname) {
+		return domain.Forbidden("user", nickname, "User %s can't act as %s", identity.Nickname, nickname)
+	}
+	return nil
+}
+
+// Authenticated is Authorize without the open mode exemption: the request
+// must carry credentials of nickname itself. It guards actions that hand
+// out credentials, which must not be open to anonymous callers.
+func Authenticated(ctx context.Context, nickname string) error {
+	identity, ok := FromContext(ctx)
+	if !ok || (identity.Nickname == "" && !identity.System) {
+		return domain.Unauthorized("Authentication required to act as %s", nickname)
+	}
+	return Authorize(ctx, nickname)
+}
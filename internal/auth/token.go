@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	signedPrefix = "v1."
+	apiPrefix    = "dbf_"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type claims struct {
+	Subject string `json:"sub"`
+	Expires int64  `json:"exp"`
+}
+
+// Signer issues and verifies the short-lived tokens returned by login. A
+// signed token is "v1." followed by the base64 claims and their HMAC-SHA256.
+type Signer struct {
+	key []byte
+	ttl time.Duration
+}
+
+// NewSigner creates a signer with the given secret. Without a secret a random
+// one is used, so tokens do not survive a restart.
+func NewSigner(secret string, ttl time.Duration) (*Signer, error) {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+	return &Signer{key: key, ttl: ttl}, nil
+}
+
+func (s *Signer) Sign(nickname string) (string, time.Time) {
+	expires := time.Now().Add(s.ttl).Truncate(time.Second)
+	payload, _ := json.Marshal(claims{Subject: nickname, Expires: expires.Unix()})
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return signedPrefix + encoded + "." + s.signature(encoded), expires
+}
+
+// Verify returns the nickname a valid, unexpired signed token was issued to.
+func (s *Signer) Verify(token string) (string, error) {
+	encoded, signature, ok := strings.Cut(strings.TrimPrefix(token, signedPrefix), ".")
+	if !ok || !IsSigned(token) || !hmac.Equal([]byte(signature), []byte(s.signature(encoded))) {
+		return "", ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil || c.Subject == "" {
+		return "", ErrInvalidToken
+	}
+	if time.Now().Unix() >= c.Expires {
+		return "", ErrInvalidToken
+	}
+	return c.Subject, nil
+}
+
+func (s *Signer) signature(encoded string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// IsSigned tells signed tokens apart from API tokens.
+func IsSigned(token string) bool {
+	return strings.HasPrefix(token, signedPrefix)
+}
+
+// NewAPIToken generates a long-lived API token. Only its hash is stored.
+func NewAPIToken() (string, []byte, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	token := apiPrefix + hex.EncodeToString(raw)
+	return token, HashAPIToken(token), nil
+}
+
+func HashAPIToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
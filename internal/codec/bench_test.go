@@ -0,0 +1,107 @@
+package codec
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/rinatkh/db_forum/internal/model/core"
+)
+
+// The benchmarks compare the codecs built into this binary on a GET thread
+// posts tree response:
+//
+//	go test -run - -bench . ./internal/codec
+const (
+	benchPostCount = 10000
+	benchDepth     = 8
+)
+
+func BenchmarkMarshal(b *testing.B) {
+	posts := benchPosts(rand.New(rand.NewSource(1)), benchPostCount, benchDepth)
+	body, err := Std().Marshal(posts)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, c := range Available() {
+		c := c
+		b.Run(c.Name(), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(body)))
+			for i := 0; i < b.N; i++ {
+				if _, err := c.Marshal(posts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	posts := benchPosts(rand.New(rand.NewSource(1)), benchPostCount, benchDepth)
+	body, err := Std().Marshal(posts)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, c := range Available() {
+		c := c
+		b.Run(c.Name(), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(body)))
+			for i := 0; i < b.N; i++ {
+				var decoded []*core.Post
+				if err := c.Unmarshal(body, &decoded); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// benchPosts returns a thread of n posts in the order of sort=tree, with
+// replies nested at most depth levels deep.
+func benchPosts(rnd *rand.Rand, n, depth int) []*core.Post {
+	posts := make([]*core.Post, 0, n)
+	// path holds the ids of the last post at each level.
+	path := make([]int64, 0, depth)
+	created := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		// Either reply to the previous post or climb up to an ancestor.
+		level := len(path)
+		if level == depth || (level > 0 && rnd.Intn(3) == 0) {
+			level = rnd.Intn(level)
+		}
+		path = path[:level]
+		var parent int64
+		if level > 0 {
+			parent = path[level-1]
+		}
+		id := int64(i + 1)
+		path = append(path, id)
+		posts = append(posts, &core.Post{
+			ID:       id,
+			Parent:   parent,
+			Author:   fmt.Sprintf("bench.user%d", rnd.Intn(1000)),
+			Forum:    "bench-forum",
+			Thread:   1,
+			Message:  benchMessage(rnd),
+			IsEdited: rnd.Intn(10) == 0,
+			Created:  created,
+		})
+	}
+	return posts
+}
+
+var benchWords = []string{"lorem", "ipsum", "dolor", "sit", "amet", "форум", "ветка", "сообщение", "\"quoted\"", "<b>tag</b>", "line\nbreak"}
+
+func benchMessage(rnd *rand.Rand) string {
+	var message []byte
+	for words := 5 + rnd.Intn(60); words > 0; words-- {
+		if len(message) > 0 {
+			message = append(message, ' ')
+		}
+		message = append(message, benchWords[rnd.Intn(len(benchWords))]...)
+	}
+	return string(message)
+}
@@ -0,0 +1,107 @@
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestJSONToMsgpack(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"scalars", `[1,-2,1.5,"a",true,false,null]`, `[1,-2,1.5,"a",true,false,null]`},
+		{"key order", `{"z":1,"a":{"y":[],"b":{}},"m":"x"}`, `{"z":1,"a":{"y":[],"b":{}},"m":"x"}`},
+		{"nested", `[[1,[2,[3]]],{"a":[{"b":null}]}]`, `[[1,[2,[3]]],{"a":[{"b":null}]}]`},
+		{"large integer", `9007199254740993`, `9007199254740993`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix := []byte{0xc0}
+			out, err := JSONToMsgpack(prefix, []byte(tt.src))
+			if err != nil {
+				t.Fatalf("JSONToMsgpack() error = %v", err)
+			}
+			if out[0] != 0xc0 {
+				t.Fatalf("JSONToMsgpack() overwrote dst")
+			}
+			var got bytes.Buffer
+			if _, err := msgp.UnmarshalAsJSON(&got, out[1:]); err != nil {
+				t.Fatalf("UnmarshalAsJSON() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("JSONToMsgpack() reads as %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+
+	if typ := msgp.NextType(mustMsgpack(t, `3`)); typ != msgp.IntType {
+		t.Errorf("integer encoded as %v", typ)
+	}
+	if typ := msgp.NextType(mustMsgpack(t, `3.0`)); typ != msgp.Float64Type {
+		t.Errorf("float encoded as %v", typ)
+	}
+	if _, err := JSONToMsgpack(nil, []byte(`{"a":`)); err == nil {
+		t.Error("JSONToMsgpack() accepted truncated JSON")
+	}
+}
+
+func mustMsgpack(t *testing.T, src string) []byte {
+	t.Helper()
+	out, err := JSONToMsgpack(nil, []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestDecompressLimit(t *testing.T) {
+	body := bytes.Repeat([]byte("forum"), 1000)
+
+	gz, err := NewGzip(gzip.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zs, err := NewZstd(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A streamed zstd frame does not declare its size up front.
+	var streamed bytes.Buffer
+	w, err := zstd.NewWriter(&streamed, zstd.WithWindowSize(1<<10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(body)
+	w.Close()
+
+	for _, c := range []Compression{gz, zs} {
+		src, err := c.Compress(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkDecompress(t, c.Name(), c, src, body)
+	}
+	checkDecompress(t, "zstd stream", zs, streamed.Bytes(), body)
+}
+
+func checkDecompress(t *testing.T, name string, c Compression, src, body []byte) {
+	t.Run(name, func(t *testing.T) {
+		out, err := c.Decompress(src, int64(len(body)))
+		if err != nil || !bytes.Equal(out, body) {
+			t.Errorf("Decompress() at the limit = %d bytes, %v", len(out), err)
+		}
+		if _, err := c.Decompress(src, int64(len(body))-1); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Decompress() over the limit error = %v, want ErrTooLarge", err)
+		}
+		if _, err := c.Decompress(src, 16); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Decompress() far over the limit error = %v, want ErrTooLarge", err)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ErrTooLarge is returned by Decompress when the output exceeds its limit.
+var ErrTooLarge = errors.New("decompressed body is too large")
+
+// Compression is a content coding of HTTP bodies.
+type Compression interface {
+	// Name is the token of the coding in Content-Encoding.
+	Name() string
+	Compress(src []byte) ([]byte, error)
+	// Decompress inflates src, failing with ErrTooLarge once the output
+	// exceeds limit bytes.
+	Decompress(src []byte, limit int64) ([]byte, error)
+}
+
+type gzipCompression struct {
+	level   int
+	writers sync.Pool
+}
+
+// NewGzip returns the gzip coding, compressing at level, which is one of the
+// compress/gzip levels.
+func NewGzip(level int) (Compression, error) {
+	if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+		return nil, err
+	}
+	return &gzipCompression{level: level}, nil
+}
+
+func (c *gzipCompression) Name() string { return "gzip" }
+
+func (c *gzipCompression) Compress(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, ok := c.writers.Get().(*gzip.Writer)
+	if ok {
+		w.Reset(&buf)
+	} else {
+		w, _ = gzip.NewWriterLevel(&buf, c.level)
+	}
+	defer c.writers.Put(w)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *gzipCompression) Decompress(src []byte, limit int64) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	out, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(out)) > limit {
+		return nil, ErrTooLarge
+	}
+	return out, nil
+}
+
+// zstdCompression shares one encoder, whose EncodeAll is safe for
+// concurrent use. Decoders are made per body, to bound their memory.
+type zstdCompression struct {
+	encoder *zstd.Encoder
+}
+
+// NewZstd returns the zstd coding, compressing at level, from 1 (fastest) to
+// 4 (best compression).
+func NewZstd(level int) (Compression, error) {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevel(level)), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &zstdCompression{encoder: encoder}, nil
+}
+
+func (c *zstdCompression) Name() string { return "zstd" }
+
+func (c *zstdCompression) Compress(src []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(src, nil), nil
+}
+
+func (c *zstdCompression) Decompress(src []byte, limit int64) ([]byte, error) {
+	r, err := zstd.NewReader(bytes.NewReader(src), zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	out, err := io.ReadAll(io.LimitReader(r, limit+1))
+	// The memory limit refuses frames that declare more output, or a larger
+	// window, than limit up front.
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+		return nil, ErrTooLarge
+	}
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(out)) > limit {
+		return nil, ErrTooLarge
+	}
+	return out, nil
+}
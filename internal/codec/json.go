@@ -0,0 +1,58 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Codec encodes and decodes JSON bodies. Implementations follow the
+// behaviour of encoding/json, so that responses do not depend on which one
+// the server picked.
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type stdCodec struct{}
+
+func (stdCodec) Name() string { return "std" }
+
+func (stdCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (stdCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// Std returns the encoding/json codec.
+func Std() Codec {
+	return stdCodec{}
+}
+
+// Available returns the codecs built into this binary, the fastest last.
+func Available() []Codec {
+	codecs := []Codec{Std()}
+	if sonic := newSonic(); sonic != nil {
+		codecs = append(codecs, sonic)
+	}
+	return codecs
+}
+
+// New returns the codec configured by name: std, sonic, or auto for sonic
+// where it is supported and std elsewhere.
+func New(name string) (Codec, error) {
+	switch name {
+	case "std":
+		return Std(), nil
+	case "sonic":
+		if sonic := newSonic(); sonic != nil {
+			return sonic, nil
+		}
+		return nil, fmt.Errorf("sonic is not supported on this platform")
+	case "auto", "":
+		if sonic := newSonic(); sonic != nil {
+			return sonic, nil
+		}
+		return Std(), nil
+	default:
+		return nil, fmt.Errorf("unknown JSON codec: %s", name)
+	}
+}
@@ -0,0 +1,84 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+const (
+	msgpackMap32   = 0xdf
+	msgpackArray32 = 0xdd
+)
+
+// JSONToMsgpack appends the MessagePack form of the JSON document src to
+// dst. Objects keep their key order, integers stay integers and the other
+// numbers become float64, so the document reads the same in both encodings.
+// Maps and arrays always get 32-bit headers, which are filled in once their
+// length is known.
+func JSONToMsgpack(dst, src []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+
+	type container struct {
+		header int
+		object bool
+		items  uint32
+	}
+	var stack []container
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return dst, err
+		}
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if top.object {
+				top.items /= 2
+			}
+			binary.BigEndian.PutUint32(dst[top.header+1:], top.items)
+			continue
+		}
+		if len(stack) > 0 {
+			stack[len(stack)-1].items++
+		}
+
+		switch value := tok.(type) {
+		case json.Delim:
+			head := byte(msgpackArray32)
+			if value == '{' {
+				head = msgpackMap32
+			}
+			stack = append(stack, container{header: len(dst), object: value == '{'})
+			dst = append(dst, head, 0, 0, 0, 0)
+		case string:
+			dst = msgp.AppendString(dst, value)
+		case json.Number:
+			if i, err := value.Int64(); err == nil {
+				dst = msgp.AppendInt64(dst, i)
+			} else if f, err := value.Float64(); err == nil {
+				dst = msgp.AppendFloat64(dst, f)
+			} else {
+				return dst, err
+			}
+		case bool:
+			dst = msgp.AppendBool(dst, value)
+		case nil:
+			dst = msgp.AppendNil(dst)
+		}
+	}
+	// The decoder reports a document cut short inside a container as a
+	// plain end of input.
+	if len(stack) > 0 {
+		return dst, io.ErrUnexpectedEOF
+	}
+	return dst, nil
+}
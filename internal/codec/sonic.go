@@ -0,0 +1,33 @@
+//go:build (amd64 && go1.17 && !go1.28) || (arm64 && go1.20 && !go1.28)
+
+package codec
+
+import (
+	"encoding/json"
+
+	"github.com/bytedance/sonic"
+)
+
+// sonicCodec encodes with the JIT of bytedance/sonic in its encoding/json
+// compatible configuration. The tags follow the platforms the pinned release
+// has a JIT for; elsewhere sonic only wraps encoding/json, so std is used.
+type sonicCodec struct {
+	api sonic.API
+}
+
+func newSonic() Codec {
+	return &sonicCodec{api: sonic.ConfigStd}
+}
+
+func (c *sonicCodec) Name() string { return "sonic" }
+
+func (c *sonicCodec) Marshal(v interface{}) ([]byte, error) { return c.api.Marshal(v) }
+
+// Unmarshal leaves input sonic refuses to encoding/json, so that callers get
+// its errors and the field names they carry.
+func (c *sonicCodec) Unmarshal(data []byte, v interface{}) error {
+	if err := c.api.Unmarshal(data, v); err != nil {
+		return json.Unmarshal(data, v)
+	}
+	return nil
+}
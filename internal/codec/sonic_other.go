@@ -0,0 +1,7 @@
+//go:build !((amd64 && go1.17 && !go1.28) || (arm64 && go1.20 && !go1.28))
+
+package codec
+
+func newSonic() Codec {
+	return nil
+}
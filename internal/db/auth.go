@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuthRepository interface {
+	SetPasswordHash(ctx context.Context, nickname string, hash string) error
+	GetPasswordHash(ctx context.Context, nickname string) (string, error)
+	CreateAPIToken(ctx context.Context, nickname string, tokenHash []byte, name string) error
+	GetAPITokenOwner(ctx context.Context, tokenHash []byte) (string, error)
+}
+
+type authRepositoryImpl struct {
+	dbConn conn
+}
+
+func (repo *authRepositoryImpl) SetPasswordHash(ctx context.Context, nickname string, hash string) error {
+	_, err := repo.dbConn.Exec(ctx,
+		`INSERT INTO Credentials (nickname, password_hash) VALUES ($1, $2)
+			ON CONFLICT (nickname) DO UPDATE SET password_hash = EXCLUDED.password_hash, updated = now();`,
+		nickname, hash)
+	return err
+}
+
+// GetPasswordHash returns the hash stored for nickname, which is looked up
+// case-insensitively like everywhere else.
+func (repo *authRepositoryImpl) GetPasswordHash(ctx context.Context, nickname string) (string, error) {
+	var hash string
+	err := repo.dbConn.QueryRow(ctx,
+		"SELECT password_hash FROM Credentials WHERE nickname = $1;", nickname).Scan(&hash)
+	return hash, err
+}
+
+func (repo *authRepositoryImpl) CreateAPIToken(ctx context.Context, nickname string, tokenHash []byte, name string) error {
+	_, err := repo.dbConn.Exec(ctx,
+		"INSERT INTO ApiTokens (token_hash, nickname, name) VALUES ($1, $2, $3);",
+		tokenHash, nickname, name)
+	return err
+}
+
+func (repo *authRepositoryImpl) GetAPITokenOwner(ctx context.Context, tokenHash []byte) (string, error) {
+	var nickname string
+	err := repo.dbConn.QueryRow(ctx,
+		"SELECT nickname FROM ApiTokens WHERE token_hash = $1;", tokenHash).Scan(&nickname)
+	return nickname, err
+}
+
+func NewAuthRepository(dbConn *pgxpool.Pool) *authRepositoryImpl {
+	return &authRepositoryImpl{dbConn: conn{pool: dbConn}}
+}
@@ -12,12 +12,16 @@ import (
 type ForumRepository interface {
 	CreateForum(ctx context.Context, forum *core.Forum) error
 	GetForum(ctx context.Context, slug string) (*core.Forum, error)
+	GetForumsBySlugs(ctx context.Context, slugs []string) ([]*core.Forum, error)
 	GetForumUsers(ctx context.Context, slug string, limit int64, since string, desc bool) ([]*core.User, error)
 	GetForumThreads(ctx context.Context, slug string, limit int64, since string, desc bool) ([]*core.Thread, error)
+	GetForumUsersPage(ctx context.Context, slug string, limit int64, desc bool, after *Keyset) ([]*core.User, error)
+	GetForumThreadsPage(ctx context.Context, slug string, limit int64, desc bool, after *Keyset) ([]*core.Thread, error)
+	DeleteForum(ctx context.Context, slug string) error
 }
 
 type forumRepositoryImpl struct {
-	dbConn *pgxpool.Pool
+	dbConn conn
 }
 
 func (repo *forumRepositoryImpl) GetForum(ctx context.Context, slug string) (*core.Forum, error) {
@@ -27,6 +31,25 @@ func (repo *forumRepositoryImpl) GetForum(ctx context.Context, slug string) (*co
 	return forum, err
 }
 
+func (repo *forumRepositoryImpl) GetForumsBySlugs(ctx context.Context, slugs []string) ([]*core.Forum, error) {
+	rows, err := repo.dbConn.Query(ctx,
+		`SELECT title, "user", slug, posts, threads FROM Forums WHERE slug = ANY($1::citext[]);`, slugs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	forums := make([]*core.Forum, 0, len(slugs))
+	for rows.Next() {
+		f := &core.Forum{}
+		if err := rows.Scan(&f.Title, &f.User, &f.Slug, &f.Posts, &f.Threads); err != nil {
+			return nil, err
+		}
+		forums = append(forums, f)
+	}
+	return forums, rows.Err()
+}
+
 func (repo *forumRepositoryImpl) GetForumUsers(ctx context.Context, slug string, limit int64, since string, desc bool) ([]*core.User, error) {
 	query := constructGetForumUsersQuery(limit, since, desc)
 	rows, err := repo.dbConn.Query(ctx, query, slug)
@@ -54,8 +77,35 @@ func (repo *forumRepositoryImpl) CreateForum(ctx context.Context, forum *core.Fo
 	return err
 }
 
+// DeleteForum removes the forum together with its threads, posts, votes and
+// participants in a single transaction.
+func (repo *forumRepositoryImpl) DeleteForum(ctx context.Context, slug string) error {
+	return repo.dbConn.BeginFunc(ctx, func(tx pgx.Tx) error {
+		queries := []string{
+			"DELETE FROM Votes WHERE thread IN (SELECT id FROM Threads WHERE forum = $1);",
+			"DELETE FROM Posts WHERE forum = $1;",
+			"DELETE FROM Threads WHERE forum = $1;",
+			"DELETE FROM ForumUsers WHERE forum = $1;",
+		}
+		for _, query := range queries {
+			if _, err := tx.Exec(ctx, query, slug); err != nil {
+				return err
+			}
+		}
+
+		res, err := tx.Exec(ctx, "DELETE FROM Forums WHERE slug = $1;", slug)
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return pgx.ErrNoRows
+		}
+		return nil
+	})
+}
+
 func NewForumRepository(dbConn *pgxpool.Pool) *forumRepositoryImpl {
-	return &forumRepositoryImpl{dbConn: dbConn}
+	return &forumRepositoryImpl{dbConn: conn{pool: dbConn}}
 }
 
 func constructGetForumUsersQuery(limit int64, since string, desc bool) string {
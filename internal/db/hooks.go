@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Call describes a single repository method invocation as seen by a Hook.
+type Call struct {
+	Repository string
+	Method     string
+	Start      time.Time
+	// Rows is the number of entities returned or affected by the call.
+	Rows int64
+	Err  error
+}
+
+// Hook is notified around every repository call of a Repository built with
+// WithHooks. BeforeQuery may return a derived context for the call.
+type Hook interface {
+	BeforeQuery(ctx context.Context, call *Call) context.Context
+	AfterQuery(ctx context.Context, call *Call)
+}
+
+type hooks []Hook
+
+func (h hooks) begin(ctx context.Context, repository, method string) (context.Context, *Call) {
+	call := &Call{Repository: repository, Method: method, Start: time.Now()}
+	for _, hook := range h {
+		ctx = hook.BeforeQuery(ctx, call)
+	}
+	return ctx, call
+}
+
+func (h hooks) end(ctx context.Context, call *Call, rows int64, err error) {
+	call.Rows = rows
+	call.Err = err
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i].AfterQuery(ctx, call)
+	}
+}
+
+func one(err error) int64 {
+	if err != nil {
+		return 0
+	}
+	return 1
+}
+
+func boolRows(ok bool) int64 {
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+// WithHooks returns a copy of repository whose calls are reported to hooks.
+func WithHooks(repository *Repository, hookList ...Hook) *Repository {
+	if len(hookList) == 0 {
+		return repository
+	}
+	h := hooks(hookList)
+	return &Repository{
+		UserRepository:        &userRepositoryHooks{next: repository.UserRepository, hooks: h},
+		ForumRepository:       &forumRepositoryHooks{next: repository.ForumRepository, hooks: h},
+		ThreadRepository:      &threadRepositoryHooks{next: repository.ThreadRepository, hooks: h},
+		VotesRepository:       &votesRepositoryHooks{next: repository.VotesRepository, hooks: h},
+		PostsRepository:       &postsRepositoryHooks{next: repository.PostsRepository, hooks: h},
+		ServiceRepository:     &serviceRepositoryHooks{next: repository.ServiceRepository, hooks: h},
+		AuthRepository:        &authRepositoryHooks{next: repository.AuthRepository, hooks: h},
+		RoleRepository:        &roleRepositoryHooks{next: repository.RoleRepository, hooks: h},
+		IdempotencyRepository: &idempotencyRepositoryHooks{next: repository.IdempotencyRepository, hooks: h},
+		Transactor:            repository.Transactor,
+	}
+}
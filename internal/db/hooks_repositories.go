@@ -0,0 +1,424 @@
+package db
+
+import (
+	"context"
+	"github.com/rinatkh/db_forum/internal/model/core"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	"time"
+)
+
+type userRepositoryHooks struct {
+	next  UserRepository
+	hooks hooks
+}
+
+func (r *userRepositoryHooks) CreateUser(ctx context.Context, user *core.User) error {
+	ctx, call := r.hooks.begin(ctx, "UserRepository", "CreateUser")
+	err := r.next.CreateUser(ctx, user)
+	r.hooks.end(ctx, call, one(err), err)
+	return err
+}
+
+func (r *userRepositoryHooks) GetUserByEmail(ctx context.Context, email string) (*core.User, error) {
+	ctx, call := r.hooks.begin(ctx, "UserRepository", "GetUserByEmail")
+	res, err := r.next.GetUserByEmail(ctx, email)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *userRepositoryHooks) GetUserByNickname(ctx context.Context, nickname string) (*core.User, error) {
+	ctx, call := r.hooks.begin(ctx, "UserRepository", "GetUserByNickname")
+	res, err := r.next.GetUserByNickname(ctx, nickname)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *userRepositoryHooks) GetUsersByEmailOrNickname(ctx context.Context, email, nickname string) ([]*core.User, error) {
+	ctx, call := r.hooks.begin(ctx, "UserRepository", "GetUsersByEmailOrNickname")
+	res, err := r.next.GetUsersByEmailOrNickname(ctx, email, nickname)
+	r.hooks.end(ctx, call, int64(len(res)), err)
+	return res, err
+}
+
+func (r *userRepositoryHooks) GetUsersByNicknames(ctx context.Context, nicknames []string) ([]*core.User, error) {
+	ctx, call := r.hooks.begin(ctx, "UserRepository", "GetUsersByNicknames")
+	res, err := r.next.GetUsersByNicknames(ctx, nicknames)
+	r.hooks.end(ctx, call, int64(len(res)), err)
+	return res, err
+}
+
+func (r *userRepositoryHooks) EditUser(ctx context.Context, user *core.User) (*core.User, error) {
+	ctx, call := r.hooks.begin(ctx, "UserRepository", "EditUser")
+	res, err := r.next.EditUser(ctx, user)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *userRepositoryHooks) SetUserBanned(ctx context.Context, nickname string, banned bool) (*core.User, error) {
+	ctx, call := r.hooks.begin(ctx, "UserRepository", "SetUserBanned")
+	res, err := r.next.SetUserBanned(ctx, nickname, banned)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *userRepositoryHooks) SetUserAdmin(ctx context.Context, nickname string, admin bool) (*core.User, error) {
+	ctx, call := r.hooks.begin(ctx, "UserRepository", "SetUserAdmin")
+	res, err := r.next.SetUserAdmin(ctx, nickname, admin)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *userRepositoryHooks) FindBannedUser(ctx context.Context, nicknames []string) (string, error) {
+	ctx, call := r.hooks.begin(ctx, "UserRepository", "FindBannedUser")
+	res, err := r.next.FindBannedUser(ctx, nicknames)
+	r.hooks.end(ctx, call, boolRows(res != ""), err)
+	return res, err
+}
+
+type forumRepositoryHooks struct {
+	next  ForumRepository
+	hooks hooks
+}
+
+func (r *forumRepositoryHooks) CreateForum(ctx context.Context, forum *core.Forum) error {
+	ctx, call := r.hooks.begin(ctx, "ForumRepository", "CreateForum")
+	err := r.next.CreateForum(ctx, forum)
+	r.hooks.end(ctx, call, one(err), err)
+	return err
+}
+
+func (r *forumRepositoryHooks) GetForum(ctx context.Context, slug string) (*core.Forum, error) {
+	ctx, call := r.hooks.begin(ctx, "ForumRepository", "GetForum")
+	res, err := r.next.GetForum(ctx, slug)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *forumRepositoryHooks) GetForumsBySlugs(ctx context.Context, slugs []string) ([]*core.Forum, error) {
+	ctx, call := r.hooks.begin(ctx, "ForumRepository", "GetForumsBySlugs")
+	res, err := r.next.GetForumsBySlugs(ctx, slugs)
+	r.hooks.end(ctx, call, int64(len(res)), err)
+	return res, err
+}
+
+func (r *forumRepositoryHooks) GetForumUsers(ctx context.Context, slug string, limit int64, since string, desc bool) ([]*core.User, error) {
+	ctx, call := r.hooks.begin(ctx, "ForumRepository", "GetForumUsers")
+	res, err := r.next.GetForumUsers(ctx, slug, limit, since, desc)
+	r.hooks.end(ctx, call, int64(len(res)), err)
+	return res, err
+}
+
+func (r *forumRepositoryHooks) GetForumThreads(ctx context.Context, slug string, limit int64, since string, desc bool) ([]*core.Thread, error) {
+	ctx, call := r.hooks.begin(ctx, "ForumRepository", "GetForumThreads")
+	res, err := r.next.GetForumThreads(ctx, slug, limit, since, desc)
+	r.hooks.end(ctx, call, int64(len(res)), err)
+	return res, err
+}
+
+func (r *forumRepositoryHooks) GetForumUsersPage(ctx context.Context, slug string, limit int64, desc bool, after *Keyset) ([]*core.User, error) {
+	ctx, call := r.hooks.begin(ctx, "ForumRepository", "GetForumUsersPage")
+	res, err := r.next.GetForumUsersPage(ctx, slug, limit, desc, after)
+	r.hooks.end(ctx, call, int64(len(res)), err)
+	return res, err
+}
+
+func (r *forumRepositoryHooks) GetForumThreadsPage(ctx context.Context, slug string, limit int64, desc bool, after *Keyset) ([]*core.Thread, error) {
+	ctx, call := r.hooks.begin(ctx, "ForumRepository", "GetForumThreadsPage")
+	res, err := r.next.GetForumThreadsPage(ctx, slug, limit, desc, after)
+	r.hooks.end(ctx, call, int64(len(res)), err)
+	return res, err
+}
+
+func (r *forumRepositoryHooks) DeleteForum(ctx context.Context, slug string) error {
+	ctx, call := r.hooks.begin(ctx, "ForumRepository", "DeleteForum")
+	err := r.next.DeleteForum(ctx, slug)
+	r.hooks.end(ctx, call, one(err), err)
+	return err
+}
+
+type threadRepositoryHooks struct {
+	next  ThreadRepository
+	hooks hooks
+}
+
+func (r *threadRepositoryHooks) CreateThread(ctx context.Context, thread *core.Thread) (*core.Thread, error) {
+	ctx, call := r.hooks.begin(ctx, "ThreadRepository", "CreateThread")
+	res, err := r.next.CreateThread(ctx, thread)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *threadRepositoryHooks) GetThreadByID(ctx context.Context, id int64) (*core.Thread, error) {
+	ctx, call := r.hooks.begin(ctx, "ThreadRepository", "GetThreadByID")
+	res, err := r.next.GetThreadByID(ctx, id)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *threadRepositoryHooks) GetThreadBySlug(ctx context.Context, slug string) (*core.Thread, error) {
+	ctx, call := r.hooks.begin(ctx, "ThreadRepository", "GetThreadBySlug")
+	res, err := r.next.GetThreadBySlug(ctx, slug)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *threadRepositoryHooks) UpdateThreadByID(ctx context.Context, id int64, title string, message string) (*core.Thread, error) {
+	ctx, call := r.hooks.begin(ctx, "ThreadRepository", "UpdateThreadByID")
+	res, err := r.next.UpdateThreadByID(ctx, id, title, message)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *threadRepositoryHooks) SetThreadLocked(ctx context.Context, id int64, locked bool) (*core.Thread, error) {
+	ctx, call := r.hooks.begin(ctx, "ThreadRepository", "SetThreadLocked")
+	res, err := r.next.SetThreadLocked(ctx, id, locked)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *threadRepositoryHooks) GetThreadsActivity(ctx context.Context, ids []int64) ([]*core.ThreadActivity, error) {
+	ctx, call := r.hooks.begin(ctx, "ThreadRepository", "GetThreadsActivity")
+	res, err := r.next.GetThreadsActivity(ctx, ids)
+	r.hooks.end(ctx, call, int64(len(res)), err)
+	return res, err
+}
+
+type votesRepositoryHooks struct {
+	next  VotesRepository
+	hooks hooks
+}
+
+func (r *votesRepositoryHooks) CreateVote(ctx context.Context, vote *core.Vote) error {
+	ctx, call := r.hooks.begin(ctx, "VotesRepository", "CreateVote")
+	err := r.next.CreateVote(ctx, vote)
+	r.hooks.end(ctx, call, one(err), err)
+	return err
+}
+
+func (r *votesRepositoryHooks) VoteExists(ctx context.Context, nickname string, threadID int64) (bool, error) {
+	ctx, call := r.hooks.begin(ctx, "VotesRepository", "VoteExists")
+	res, err := r.next.VoteExists(ctx, nickname, threadID)
+	r.hooks.end(ctx, call, boolRows(res), err)
+	return res, err
+}
+
+func (r *votesRepositoryHooks) EditVote(ctx context.Context, threadID int64, nickname string, voice int64) (bool, error) {
+	ctx, call := r.hooks.begin(ctx, "VotesRepository", "EditVote")
+	res, err := r.next.EditVote(ctx, threadID, nickname, voice)
+	r.hooks.end(ctx, call, boolRows(res), err)
+	return res, err
+}
+
+type postsRepositoryHooks struct {
+	next  PostsRepository
+	hooks hooks
+}
+
+func (r *postsRepositoryHooks) CreatePosts(ctx context.Context, forum string, thread int64, posts []*dto.Post) ([]*core.Post, error) {
+	ctx, call := r.hooks.begin(ctx, "PostsRepository", "CreatePosts")
+	res, err := r.next.CreatePosts(ctx, forum, thread, posts)
+	r.hooks.end(ctx, call, int64(len(res)), err)
+	return res, err
+}
+
+func (r *postsRepositoryHooks) CheckParentPost(ctx context.Context, parent int) (int, error) {
+	ctx, call := r.hooks.begin(ctx, "PostsRepository", "CheckParentPost")
+	res, err := r.next.CheckParentPost(ctx, parent)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *postsRepositoryHooks) GetPostsFlat(ctx context.Context, id int, since int64, desc bool, limit int64) ([]*core.Post, error) {
+	ctx, call := r.hooks.begin(ctx, "PostsRepository", "GetPostsFlat")
+	res, err := r.next.GetPostsFlat(ctx, id, since, desc, limit)
+	r.hooks.end(ctx, call, int64(len(res)), err)
+	return res, err
+}
+
+func (r *postsRepositoryHooks) GetPostsTree(ctx context.Context, id int, since int64, desc bool, limit int64) ([]*core.Post, error) {
+	ctx, call := r.hooks.begin(ctx, "PostsRepository", "GetPostsTree")
+	res, err := r.next.GetPostsTree(ctx, id, since, desc, limit)
+	r.hooks.end(ctx, call, int64(len(res)), err)
+	return res, err
+}
+
+func (r *postsRepositoryHooks) GetPostsParentTree(ctx context.Context, id int, since int64, desc bool, limit int64) ([]*core.Post, error) {
+	ctx, call := r.hooks.begin(ctx, "PostsRepository", "GetPostsParentTree")
+	res, err := r.next.GetPostsParentTree(ctx, id, since, desc, limit)
+	r.hooks.end(ctx, call, int64(len(res)), err)
+	return res, err
+}
+
+func (r *postsRepositoryHooks) GetPostsPage(ctx context.Context, thread int, sort string, limit int64, desc bool, after *Keyset) ([]*core.Post, error) {
+	ctx, call := r.hooks.begin(ctx, "PostsRepository", "GetPostsPage")
+	res, err := r.next.GetPostsPage(ctx, thread, sort, limit, desc, after)
+	r.hooks.end(ctx, call, int64(len(res)), err)
+	return res, err
+}
+
+func (r *postsRepositoryHooks) GetPostDetails(ctx context.Context, id int64, related string) (dto.PostDetails, error) {
+	ctx, call := r.hooks.begin(ctx, "PostsRepository", "GetPostDetails")
+	res, err := r.next.GetPostDetails(ctx, id, related)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *postsRepositoryHooks) GetPostByID(ctx context.Context, id int64) (*core.Post, error) {
+	ctx, call := r.hooks.begin(ctx, "PostsRepository", "GetPostByID")
+	res, err := r.next.GetPostByID(ctx, id)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *postsRepositoryHooks) GetPostsDepth(ctx context.Context, ids []int64) ([]*core.PostDepth, error) {
+	ctx, call := r.hooks.begin(ctx, "PostsRepository", "GetPostsDepth")
+	res, err := r.next.GetPostsDepth(ctx, ids)
+	r.hooks.end(ctx, call, int64(len(res)), err)
+	return res, err
+}
+
+func (r *postsRepositoryHooks) EditPost(ctx context.Context, id int64, message string) (*core.Post, error) {
+	ctx, call := r.hooks.begin(ctx, "PostsRepository", "EditPost")
+	res, err := r.next.EditPost(ctx, id, message)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *postsRepositoryHooks) FindDuplicatePost(ctx context.Context, thread int64, authors []string, messages []string, since time.Time) (string, error) {
+	ctx, call := r.hooks.begin(ctx, "PostsRepository", "FindDuplicatePost")
+	res, err := r.next.FindDuplicatePost(ctx, thread, authors, messages, since)
+	r.hooks.end(ctx, call, boolRows(res != ""), err)
+	return res, err
+}
+
+type serviceRepositoryHooks struct {
+	next  ServiceRepository
+	hooks hooks
+}
+
+func (r *serviceRepositoryHooks) Status(ctx context.Context) (*core.ServiceInfo, error) {
+	ctx, call := r.hooks.begin(ctx, "ServiceRepository", "Status")
+	res, err := r.next.Status(ctx)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *serviceRepositoryHooks) Delete(ctx context.Context) error {
+	ctx, call := r.hooks.begin(ctx, "ServiceRepository", "Delete")
+	err := r.next.Delete(ctx)
+	r.hooks.end(ctx, call, one(err), err)
+	return err
+}
+
+func (r *serviceRepositoryHooks) Reconcile(ctx context.Context) error {
+	ctx, call := r.hooks.begin(ctx, "ServiceRepository", "Reconcile")
+	err := r.next.Reconcile(ctx)
+	r.hooks.end(ctx, call, one(err), err)
+	return err
+}
+
+func (r *serviceRepositoryHooks) Export(ctx context.Context, write func(record *core.DumpRecord) error) error {
+	ctx, call := r.hooks.begin(ctx, "ServiceRepository", "Export")
+	err := r.next.Export(ctx, write)
+	r.hooks.end(ctx, call, one(err), err)
+	return err
+}
+
+func (r *serviceRepositoryHooks) Import(ctx context.Context, next func() (*core.DumpRecord, error)) (int64, error) {
+	ctx, call := r.hooks.begin(ctx, "ServiceRepository", "Import")
+	res, err := r.next.Import(ctx, next)
+	r.hooks.end(ctx, call, res, err)
+	return res, err
+}
+
+type authRepositoryHooks struct {
+	next  AuthRepository
+	hooks hooks
+}
+
+func (r *authRepositoryHooks) SetPasswordHash(ctx context.Context, nickname string, hash string) error {
+	ctx, call := r.hooks.begin(ctx, "AuthRepository", "SetPasswordHash")
+	err := r.next.SetPasswordHash(ctx, nickname, hash)
+	r.hooks.end(ctx, call, one(err), err)
+	return err
+}
+
+func (r *authRepositoryHooks) GetPasswordHash(ctx context.Context, nickname string) (string, error) {
+	ctx, call := r.hooks.begin(ctx, "AuthRepository", "GetPasswordHash")
+	res, err := r.next.GetPasswordHash(ctx, nickname)
+	r.hooks.end(ctx, call, boolRows(res != ""), err)
+	return res, err
+}
+
+func (r *authRepositoryHooks) CreateAPIToken(ctx context.Context, nickname string, tokenHash []byte, name string) error {
+	ctx, call := r.hooks.begin(ctx, "AuthRepository", "CreateAPIToken")
+	err := r.next.CreateAPIToken(ctx, nickname, tokenHash, name)
+	r.hooks.end(ctx, call, one(err), err)
+	return err
+}
+
+func (r *authRepositoryHooks) GetAPITokenOwner(ctx context.Context, tokenHash []byte) (string, error) {
+	ctx, call := r.hooks.begin(ctx, "AuthRepository", "GetAPITokenOwner")
+	res, err := r.next.GetAPITokenOwner(ctx, tokenHash)
+	r.hooks.end(ctx, call, boolRows(res != ""), err)
+	return res, err
+}
+
+type roleRepositoryHooks struct {
+	next  RoleRepository
+	hooks hooks
+}
+
+func (r *roleRepositoryHooks) GetMembership(ctx context.Context, nickname string, forum string) (*core.Membership, error) {
+	ctx, call := r.hooks.begin(ctx, "RoleRepository", "GetMembership")
+	res, err := r.next.GetMembership(ctx, nickname, forum)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *roleRepositoryHooks) GetModerators(ctx context.Context, forum string) ([]*core.User, error) {
+	ctx, call := r.hooks.begin(ctx, "RoleRepository", "GetModerators")
+	res, err := r.next.GetModerators(ctx, forum)
+	r.hooks.end(ctx, call, int64(len(res)), err)
+	return res, err
+}
+
+func (r *roleRepositoryHooks) AddModerator(ctx context.Context, forum string, nickname string, grantedBy string) error {
+	ctx, call := r.hooks.begin(ctx, "RoleRepository", "AddModerator")
+	err := r.next.AddModerator(ctx, forum, nickname, grantedBy)
+	r.hooks.end(ctx, call, one(err), err)
+	return err
+}
+
+func (r *roleRepositoryHooks) RemoveModerator(ctx context.Context, forum string, nickname string) (bool, error) {
+	ctx, call := r.hooks.begin(ctx, "RoleRepository", "RemoveModerator")
+	res, err := r.next.RemoveModerator(ctx, forum, nickname)
+	r.hooks.end(ctx, call, boolRows(res), err)
+	return res, err
+}
+
+type idempotencyRepositoryHooks struct {
+	next  IdempotencyRepository
+	hooks hooks
+}
+
+func (r *idempotencyRepositoryHooks) ReserveKey(ctx context.Context, scope string, key string, requestHash []byte, expires time.Time) (*core.IdempotentResponse, error) {
+	ctx, call := r.hooks.begin(ctx, "IdempotencyRepository", "ReserveKey")
+	res, err := r.next.ReserveKey(ctx, scope, key, requestHash, expires)
+	r.hooks.end(ctx, call, one(err), err)
+	return res, err
+}
+
+func (r *idempotencyRepositoryHooks) CompleteKey(ctx context.Context, scope string, key string, response *core.IdempotentResponse) error {
+	ctx, call := r.hooks.begin(ctx, "IdempotencyRepository", "CompleteKey")
+	err := r.next.CompleteKey(ctx, scope, key, response)
+	r.hooks.end(ctx, call, one(err), err)
+	return err
+}
+
+func (r *idempotencyRepositoryHooks) ReleaseKey(ctx context.Context, scope string, key string) error {
+	ctx, call := r.hooks.begin(ctx, "IdempotencyRepository", "ReleaseKey")
+	err := r.next.ReleaseKey(ctx, scope, key)
+	r.hooks.end(ctx, call, one(err), err)
+	return err
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rinatkh/db_forum/internal/model/core"
+)
+
+type IdempotencyRepository interface {
+	ReserveKey(ctx context.Context, scope string, key string, requestHash []byte, expires time.Time) (*core.IdempotentResponse, error)
+	CompleteKey(ctx context.Context, scope string, key string, response *core.IdempotentResponse) error
+	ReleaseKey(ctx context.Context, scope string, key string) error
+}
+
+type idempotencyRepositoryImpl struct {
+	dbConn conn
+}
+
+// expiredKeysBatch bounds how many expired keys a reservation drops, so that
+// the cleanup cost stays small and constant on the request path.
+const expiredKeysBatch = 16
+
+// ReserveKey claims key within scope for a new request and returns nil, or
+// returns what is stored under it when it is taken. An expired key is claimed
+// anew, and a few expired keys of any scope are dropped on the way.
+func (repo *idempotencyRepositoryImpl) ReserveKey(ctx context.Context, scope string, key string, requestHash []byte, expires time.Time) (*core.IdempotentResponse, error) {
+	if _, err := repo.dbConn.Exec(ctx,
+		`DELETE FROM IdempotencyKeys WHERE ctid = ANY(ARRAY(
+			SELECT ctid FROM IdempotencyKeys WHERE expires < now() LIMIT $1 FOR UPDATE SKIP LOCKED));`,
+		expiredKeysBatch); err != nil {
+		return nil, err
+	}
+
+	res, err := repo.dbConn.Exec(ctx,
+		`INSERT INTO IdempotencyKeys (scope, key, request_hash, expires) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (scope, key) DO UPDATE
+				SET request_hash = EXCLUDED.request_hash, status = 0, content_type = '', body = NULL, expires = EXCLUDED.expires
+				WHERE IdempotencyKeys.expires < now();`,
+		scope, key, requestHash, expires)
+	if err != nil {
+		return nil, err
+	}
+	if res.RowsAffected() == 1 {
+		return nil, nil
+	}
+
+	stored := &core.IdempotentResponse{}
+	err = repo.dbConn.QueryRow(ctx,
+		"SELECT request_hash, status, content_type, body FROM IdempotencyKeys WHERE scope = $1 AND key = $2;",
+		scope, key).Scan(&stored.RequestHash, &stored.Status, &stored.ContentType, &stored.Body)
+	if err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+func (repo *idempotencyRepositoryImpl) CompleteKey(ctx context.Context, scope string, key string, response *core.IdempotentResponse) error {
+	_, err := repo.dbConn.Exec(ctx,
+		"UPDATE IdempotencyKeys SET status = $3, content_type = $4, body = $5 WHERE scope = $1 AND key = $2;",
+		scope, key, response.Status, response.ContentType, response.Body)
+	return err
+}
+
+// ReleaseKey forgets a reservation, so that a retry with the key runs again.
+func (repo *idempotencyRepositoryImpl) ReleaseKey(ctx context.Context, scope string, key string) error {
+	_, err := repo.dbConn.Exec(ctx,
+		"DELETE FROM IdempotencyKeys WHERE scope = $1 AND key = $2 AND status = 0;", scope, key)
+	return err
+}
+
+func NewIdempotencyRepository(dbConn *pgxpool.Pool) *idempotencyRepositoryImpl {
+	return &idempotencyRepositoryImpl{dbConn: conn{pool: dbConn}}
+}
@@ -0,0 +1,176 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rinatkh/db_forum/internal/model/core"
+)
+
+// Keyset positions a listing page next to a row the client has already seen:
+// strictly after it, or strictly before it when Backward is set. Key is the
+// sort key of that row as text and ID breaks ties between equal keys.
+type Keyset struct {
+	Key      string
+	ID       int64
+	Backward bool
+}
+
+// scan returns the comparison operator and the sort direction that walk a
+// listing shown in the given order away from the keyset row. Backward pages
+// are read in reverse and have to be flipped into display order afterwards.
+func (k *Keyset) scan(desc bool) (string, string) {
+	if desc != (k != nil && k.Backward) {
+		return "<", "DESC"
+	}
+	return ">", "ASC"
+}
+
+func (k *Keyset) backward() bool {
+	return k != nil && k.Backward
+}
+
+func reverse[T any](items []T) {
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+}
+
+func (repo *forumRepositoryImpl) GetForumThreadsPage(ctx context.Context, slug string, limit int64, desc bool, after *Keyset) ([]*core.Thread, error) {
+	cmp, dir := after.scan(desc)
+	query := "SELECT id, title, author, forum, message, votes, slug, created FROM Threads WHERE forum = $1 "
+	args := []interface{}{slug}
+	if after != nil {
+		query += fmt.Sprintf("AND (created, id) %s ($2::timestamptz, $3) ", cmp)
+		args = append(args, after.Key, after.ID)
+	}
+	query += fmt.Sprintf("ORDER BY created %s, id %s LIMIT %d;", dir, dir, limit)
+
+	rows, err := repo.dbConn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	threads := make([]*core.Thread, 0, limit)
+	for rows.Next() {
+		t := &core.Thread{}
+		if err := rows.Scan(&t.ID, &t.Title, &t.Author, &t.Forum, &t.Message, &t.Votes, &t.Slug, &t.Created); err != nil {
+			return nil, err
+		}
+		threads = append(threads, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if after.backward() {
+		reverse(threads)
+	}
+	return threads, nil
+}
+
+// GetForumUsersPage pages by nickname alone, as it is unique within a forum.
+func (repo *forumRepositoryImpl) GetForumUsersPage(ctx context.Context, slug string, limit int64, desc bool, after *Keyset) ([]*core.User, error) {
+	cmp, dir := after.scan(desc)
+	query := "SELECT nickname, fullname, about, email FROM ForumUsers WHERE forum = $1 "
+	args := []interface{}{slug}
+	if after != nil {
+		query += fmt.Sprintf("AND nickname %s $2 ", cmp)
+		args = append(args, after.Key)
+	}
+	query += fmt.Sprintf("ORDER BY nickname %s LIMIT %d;", dir, limit)
+
+	rows, err := repo.dbConn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*core.User, 0, limit)
+	for rows.Next() {
+		u := &core.User{}
+		if err := rows.Scan(&u.Nickname, &u.Fullname, &u.About, &u.Email); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if after.backward() {
+		reverse(users)
+	}
+	return users, nil
+}
+
+// GetPostsPage returns posts of a thread in display order. Flat listings page
+// by (created, id) and tree listings by the path of the post with the keyset
+// id. Parent tree listings page by root post id and limit the number of roots,
+// returning every post below them.
+func (repo *postsRepositoryImpl) GetPostsPage(ctx context.Context, thread int, sort string, limit int64, desc bool, after *Keyset) ([]*core.Post, error) {
+	query, args := postsPageQuery(thread, sort, limit, desc, after)
+
+	rows, err := repo.dbConn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := make([]*core.Post, 0, limit)
+	for rows.Next() {
+		post := &core.Post{}
+		if err := rows.Scan(&post.ID, &post.Parent, &post.Author, &post.Message, &post.IsEdited, &post.Forum, &post.Thread, &post.Created); err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Parent tree queries already sort the selected roots for display.
+	if after.backward() && sort != "parent_tree" {
+		reverse(posts)
+	}
+	return posts, nil
+}
+
+// postsPageQuery builds the query of GetPostsPage.
+func postsPageQuery(thread int, sort string, limit int64, desc bool, after *Keyset) (string, []interface{}) {
+	const columns = "id, parent, author, message, isEdited, forum, thread, created"
+	cmp, dir := after.scan(desc)
+	args := []interface{}{thread}
+
+	var query string
+	switch sort {
+	case "tree":
+		query = "SELECT " + columns + " FROM Posts WHERE thread = $1 "
+		if after != nil {
+			query += fmt.Sprintf("AND path %s (SELECT path FROM Posts WHERE id = $2) ", cmp)
+			args = append(args, after.ID)
+		}
+		query += fmt.Sprintf("ORDER BY path %s LIMIT %d;", dir, limit)
+	case "parent_tree":
+		roots := "SELECT id FROM Posts WHERE thread = $1 AND parent = 0 "
+		if after != nil {
+			roots += fmt.Sprintf("AND id %s $2 ", cmp)
+			args = append(args, after.ID)
+		}
+		roots += fmt.Sprintf("ORDER BY id %s LIMIT %d", dir, limit)
+		rootOrder := "ASC"
+		if desc {
+			rootOrder = "DESC"
+		}
+		query = fmt.Sprintf("SELECT %s FROM Posts WHERE path[1] IN (%s) ORDER BY path[1] %s, path ASC;", columns, roots, rootOrder)
+	default:
+		query = "SELECT " + columns + " FROM Posts WHERE thread = $1 "
+		if after != nil {
+			query += fmt.Sprintf("AND (created, id) %s ($2::timestamptz, $3) ", cmp)
+			args = append(args, after.Key, after.ID)
+		}
+		query += fmt.Sprintf("ORDER BY created %s, id %s LIMIT %d;", dir, dir, limit)
+	}
+	return query, args
+}
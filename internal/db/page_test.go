@@ -0,0 +1,93 @@
+package db
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeysetScan(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyset   *Keyset
+		desc     bool
+		cmp, dir string
+	}{
+		{"first page", nil, false, ">", "ASC"},
+		{"first page desc", nil, true, "<", "DESC"},
+		{"forward", &Keyset{ID: 1}, false, ">", "ASC"},
+		{"forward desc", &Keyset{ID: 1}, true, "<", "DESC"},
+		{"backward", &Keyset{ID: 1, Backward: true}, false, "<", "DESC"},
+		{"backward desc", &Keyset{ID: 1, Backward: true}, true, ">", "ASC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmp, dir := tt.keyset.scan(tt.desc)
+			if cmp != tt.cmp || dir != tt.dir {
+				t.Errorf("scan(%v) = %q, %q, want %q, %q", tt.desc, cmp, dir, tt.cmp, tt.dir)
+			}
+		})
+	}
+}
+
+func TestParentTreeRoots(t *testing.T) {
+	tests := []struct {
+		name  string
+		after *Keyset
+		desc  bool
+		want  []string
+		args  []interface{}
+	}{
+		{
+			name: "first page",
+			want: []string{"parent = 0 ORDER BY id ASC LIMIT 3)", "ORDER BY path[1] ASC, path ASC;"},
+			args: []interface{}{7},
+		},
+		{
+			name: "first page desc",
+			desc: true,
+			want: []string{"parent = 0 ORDER BY id DESC LIMIT 3)", "ORDER BY path[1] DESC, path ASC;"},
+			args: []interface{}{7},
+		},
+		{
+			name:  "forward",
+			after: &Keyset{ID: 42},
+			want:  []string{"AND id > $2 ORDER BY id ASC LIMIT 3)", "ORDER BY path[1] ASC, path ASC;"},
+			args:  []interface{}{7, int64(42)},
+		},
+		{
+			name:  "forward desc",
+			after: &Keyset{ID: 42},
+			desc:  true,
+			want:  []string{"AND id < $2 ORDER BY id DESC LIMIT 3)", "ORDER BY path[1] DESC, path ASC;"},
+			args:  []interface{}{7, int64(42)},
+		},
+		{
+			// The roots nearest the cursor are selected, but shown in display order.
+			name:  "backward",
+			after: &Keyset{ID: 42, Backward: true},
+			want:  []string{"AND id < $2 ORDER BY id DESC LIMIT 3)", "ORDER BY path[1] ASC, path ASC;"},
+			args:  []interface{}{7, int64(42)},
+		},
+		{
+			name:  "backward desc",
+			after: &Keyset{ID: 42, Backward: true},
+			desc:  true,
+			want:  []string{"AND id > $2 ORDER BY id ASC LIMIT 3)", "ORDER BY path[1] DESC, path ASC;"},
+			args:  []interface{}{7, int64(42)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := postsPageQuery(7, "parent_tree", 3, tt.desc, tt.after)
+			for _, want := range tt.want {
+				if !strings.Contains(query, want) {
+					t.Errorf("query %q does not contain %q", query, want)
+				}
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+		})
+	}
+}
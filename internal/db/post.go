@@ -2,9 +2,9 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/labstack/gommon/log"
 	"github.com/rinatkh/db_forum/internal/model/core"
 	"github.com/rinatkh/db_forum/internal/model/dto"
 	"strings"
@@ -19,13 +19,16 @@ type PostsRepository interface {
 	GetPostsFlat(ctx context.Context, id int, since int64, desc bool, limit int64) ([]*core.Post, error)
 	GetPostsTree(ctx context.Context, id int, since int64, desc bool, limit int64) ([]*core.Post, error)
 	GetPostsParentTree(ctx context.Context, id int, since int64, desc bool, limit int64) ([]*core.Post, error)
+	GetPostsPage(ctx context.Context, thread int, sort string, limit int64, desc bool, after *Keyset) ([]*core.Post, error)
 	GetPostDetails(ctx context.Context, id int64, related string) (dto.PostDetails, error)
 	GetPostByID(ctx context.Context, id int64) (*core.Post, error)
+	GetPostsDepth(ctx context.Context, ids []int64) ([]*core.PostDepth, error)
 	EditPost(ctx context.Context, id int64, message string) (*core.Post, error)
+	FindDuplicatePost(ctx context.Context, thread int64, authors []string, messages []string, since time.Time) (string, error)
 }
 
 type postsRepositoryImpl struct {
-	dbConn *pgxpool.Pool
+	dbConn conn
 }
 
 func (repo *postsRepositoryImpl) CreatePosts(ctx context.Context, forum string, thread int64, posts []*dto.Post) ([]*core.Post, error) {
@@ -199,7 +202,6 @@ func (repo *postsRepositoryImpl) GetPostDetails(ctx context.Context, id int64, r
 			postDetails.Forum = forum
 		}
 	}
-	log.Infof("%v", postDetails)
 	return postDetails, nil
 }
 
@@ -244,12 +246,31 @@ func (repo *postsRepositoryImpl) GetPostsTree(ctx context.Context, id int, since
 func (repo *postsRepositoryImpl) GetPostByID(ctx context.Context, id int64) (*core.Post, error) {
 	post := &core.Post{}
 	err := repo.dbConn.QueryRow(ctx,
-		"SELECT id, parent, author, message, isEdited, forum, thread, created FROM Posts WHERE id = $1;",
+		"SELECT id, parent, author, message, isEdited, forum, thread, created, modified FROM Posts WHERE id = $1;",
 		id).
-		Scan(&post.ID, &post.Parent, &post.Author, &post.Message, &post.IsEdited, &post.Forum, &post.Thread, &post.Created)
+		Scan(&post.ID, &post.Parent, &post.Author, &post.Message, &post.IsEdited, &post.Forum, &post.Thread, &post.Created, &post.Modified)
 	return post, err
 }
 
+func (repo *postsRepositoryImpl) GetPostsDepth(ctx context.Context, ids []int64) ([]*core.PostDepth, error) {
+	rows, err := repo.dbConn.Query(ctx,
+		"SELECT id, array_length(path, 1) - 1 FROM Posts WHERE id = ANY($1::bigint[]);", ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	depths := make([]*core.PostDepth, 0, len(ids))
+	for rows.Next() {
+		d := &core.PostDepth{}
+		if err := rows.Scan(&d.ID, &d.Depth); err != nil {
+			return nil, err
+		}
+		depths = append(depths, d)
+	}
+	return depths, rows.Err()
+}
+
 func (repo *postsRepositoryImpl) EditPost(ctx context.Context, id int64, message string) (*core.Post, error) {
 	post := &core.Post{}
 	err := repo.dbConn.QueryRow(ctx,
@@ -263,6 +284,25 @@ func (repo *postsRepositoryImpl) EditPost(ctx context.Context, id int64, message
 	return post, nil
 }
 
+// FindDuplicatePost returns the author of a post in thread created after
+// since that has the same author and message as one of the given pairs, or
+// an empty string.
+func (repo *postsRepositoryImpl) FindDuplicatePost(ctx context.Context, thread int64, authors []string, messages []string, since time.Time) (string, error) {
+	var author string
+	err := repo.dbConn.QueryRow(ctx,
+		`SELECT p.author FROM Posts p
+			JOIN unnest($2::citext[], $3::text[]) AS n(author, message) ON p.author = n.author AND p.message = n.message
+			WHERE p.thread = $1 AND p.created > $4 LIMIT 1;`,
+		thread, authors, messages, since).Scan(&author)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return author, nil
+}
+
 func NewPostsRepository(dbConn *pgxpool.Pool) *postsRepositoryImpl {
-	return &postsRepositoryImpl{dbConn: dbConn}
+	return &postsRepositoryImpl{dbConn: conn{pool: dbConn}}
 }
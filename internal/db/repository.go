@@ -5,12 +5,16 @@ import (
 )
 
 type Repository struct {
-	UserRepository    UserRepository
-	ForumRepository   ForumRepository
-	ThreadRepository  ThreadRepository
-	VotesRepository   VotesRepository
-	PostsRepository   PostsRepository
-	ServiceRepository ServiceRepository
+	UserRepository        UserRepository
+	ForumRepository       ForumRepository
+	ThreadRepository      ThreadRepository
+	VotesRepository       VotesRepository
+	PostsRepository       PostsRepository
+	ServiceRepository     ServiceRepository
+	AuthRepository        AuthRepository
+	RoleRepository        RoleRepository
+	IdempotencyRepository IdempotencyRepository
+	Transactor            Transactor
 }
 
 func NewRepository(dbConn *pgxpool.Pool) (*Repository, error) {
@@ -22,5 +26,9 @@ func NewRepository(dbConn *pgxpool.Pool) (*Repository, error) {
 	repository.VotesRepository = NewVotesRepository(dbConn)
 	repository.PostsRepository = NewPostsRepository(dbConn)
 	repository.ServiceRepository = NewServiceRepository(dbConn)
+	repository.AuthRepository = NewAuthRepository(dbConn)
+	repository.RoleRepository = NewRoleRepository(dbConn)
+	repository.IdempotencyRepository = NewIdempotencyRepository(dbConn)
+	repository.Transactor = NewTransactor(dbConn)
 	return repository, nil
 }
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rinatkh/db_forum/internal/model/core"
+)
+
+type RoleRepository interface {
+	GetMembership(ctx context.Context, nickname string, forum string) (*core.Membership, error)
+	GetModerators(ctx context.Context, forum string) ([]*core.User, error)
+	AddModerator(ctx context.Context, forum string, nickname string, grantedBy string) error
+	RemoveModerator(ctx context.Context, forum string, nickname string) (bool, error)
+}
+
+type roleRepositoryImpl struct {
+	dbConn conn
+}
+
+// GetMembership loads the site flags of a user and their role in forum, which
+// may be empty for site-wide decisions.
+func (repo *roleRepositoryImpl) GetMembership(ctx context.Context, nickname string, forum string) (*core.Membership, error) {
+	m := &core.Membership{}
+	err := repo.dbConn.QueryRow(ctx,
+		`SELECT u.nickname, u.admin, u.banned,
+				COALESCE(f."user" = u.nickname, false),
+				EXISTS (SELECT 1 FROM ForumModerators fm WHERE fm.forum = $2 AND fm.nickname = u.nickname)
+			FROM Users u LEFT JOIN Forums f ON f.slug = $2
+			WHERE u.nickname = $1;`,
+		nickname, forum).Scan(&m.Nickname, &m.Admin, &m.Banned, &m.Owner, &m.Moderator)
+	return m, err
+}
+
+func (repo *roleRepositoryImpl) GetModerators(ctx context.Context, forum string) ([]*core.User, error) {
+	rows, err := repo.dbConn.Query(ctx,
+		`SELECT u.nickname, u.fullname, u.about, u.email FROM ForumModerators fm
+			JOIN Users u ON u.nickname = fm.nickname
+			WHERE fm.forum = $1 ORDER BY u.nickname;`, forum)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*core.User, 0)
+	for rows.Next() {
+		u := &core.User{}
+		if err := rows.Scan(&u.Nickname, &u.Fullname, &u.About, &u.Email); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (repo *roleRepositoryImpl) AddModerator(ctx context.Context, forum string, nickname string, grantedBy string) error {
+	_, err := repo.dbConn.Exec(ctx,
+		"INSERT INTO ForumModerators (forum, nickname, granted_by) VALUES ($1, $2, NULLIF($3, '')) ON CONFLICT DO NOTHING;",
+		forum, nickname, grantedBy)
+	return err
+}
+
+func (repo *roleRepositoryImpl) RemoveModerator(ctx context.Context, forum string, nickname string) (bool, error) {
+	res, err := repo.dbConn.Exec(ctx,
+		"DELETE FROM ForumModerators WHERE forum = $1 AND nickname = $2;", forum, nickname)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+func NewRoleRepository(dbConn *pgxpool.Pool) *roleRepositoryImpl {
+	return &roleRepositoryImpl{dbConn: conn{pool: dbConn}}
+}
@@ -2,22 +2,31 @@ package db
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rinatkh/db_forum/internal/model/core"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
 type ServiceRepository interface {
 	Status(ctx context.Context) (*core.ServiceInfo, error)
 	Delete(ctx context.Context) error
+	Reconcile(ctx context.Context) error
+	Export(ctx context.Context, write func(record *core.DumpRecord) error) error
+	Import(ctx context.Context, next func() (*core.DumpRecord, error)) (int64, error)
 }
 
 type serviceRepositoryImpl struct {
-	dbConn *pgxpool.Pool
+	dbConn conn
 }
 
 func (repo *serviceRepositoryImpl) Delete(ctx context.Context) error {
 	_, err := repo.dbConn.Exec(ctx,
-		"TRUNCATE TABLE Users, Forums, Threads, Posts, ForumUsers, Votes CASCADE;")
+		"TRUNCATE TABLE Users, Forums, Threads, Posts, ForumUsers, Votes, IdempotencyKeys CASCADE;")
 	return err
 }
 
@@ -28,6 +37,234 @@ func (repo *serviceRepositoryImpl) Status(ctx context.Context) (*core.ServiceInf
 	return res, err
 }
 
+// Reconcile recomputes the denormalized counters and the ForumUsers table
+// from the source rows, fixing any drift left by the triggers.
+func (repo *serviceRepositoryImpl) Reconcile(ctx context.Context) error {
+	return repo.dbConn.BeginFunc(ctx, func(tx pgx.Tx) error {
+		queries := []string{
+			`UPDATE Forums f SET
+				threads = (SELECT count(*) FROM Threads t WHERE t.forum = f.slug),
+				posts = (SELECT count(*) FROM Posts p WHERE p.forum = f.slug);`,
+			`UPDATE Threads t SET votes = COALESCE((SELECT sum(v.voice) FROM Votes v WHERE v.thread = t.id), 0);`,
+			`UPDATE Threads t SET posts = c.posts, last_post = c.last_post
+				FROM (SELECT t.id, count(p.id) AS posts, max(p.created) AS last_post
+					FROM Threads t LEFT JOIN Posts p ON p.thread = t.id GROUP BY t.id) c
+				WHERE t.id = c.id AND (t.posts, t.last_post) IS DISTINCT FROM (c.posts, c.last_post);`,
+			`INSERT INTO ForumUsers (nickname, fullname, about, email, forum)
+				SELECT u.nickname, u.fullname, u.about, u.email, a.forum
+				FROM (SELECT author, forum FROM Threads UNION SELECT author, forum FROM Posts) a
+				JOIN Users u ON u.nickname = a.author
+				ON CONFLICT DO NOTHING;`,
+			`UPDATE ForumUsers fu SET fullname = u.fullname, about = u.about, email = u.email
+				FROM Users u WHERE u.nickname = fu.nickname;`,
+		}
+		for _, query := range queries {
+			if _, err := tx.Exec(ctx, query); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Export streams every row of the forum tables to write, parents first, so
+// that the output can be fed back to Import.
+func (repo *serviceRepositoryImpl) Export(ctx context.Context, write func(record *core.DumpRecord) error) error {
+	return repo.dbConn.BeginTxFunc(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		if err := exportRows(ctx, tx, "SELECT nickname, fullname, about, email, banned, admin FROM Users ORDER BY nickname;",
+			func(rows pgx.Rows) (*core.DumpRecord, error) {
+				u := &core.User{}
+				record := &core.DumpRecord{Type: core.DumpUser, User: u}
+				err := rows.Scan(&u.Nickname, &u.Fullname, &u.About, &u.Email, &u.Banned, &record.Admin)
+				record.Banned = u.Banned
+				return record, err
+			}, write); err != nil {
+			return err
+		}
+
+		if err := exportRows(ctx, tx, `SELECT title, "user", slug, posts, threads FROM Forums ORDER BY slug;`,
+			func(rows pgx.Rows) (*core.DumpRecord, error) {
+				f := &core.Forum{}
+				err := rows.Scan(&f.Title, &f.User, &f.Slug, &f.Posts, &f.Threads)
+				return &core.DumpRecord{Type: core.DumpForum, Forum: f}, err
+			}, write); err != nil {
+			return err
+		}
+
+		if err := exportRows(ctx, tx, "SELECT id, title, author, forum, message, votes, slug, created, locked, modified FROM Threads ORDER BY id;",
+			func(rows pgx.Rows) (*core.DumpRecord, error) {
+				t := &core.Thread{}
+				err := rows.Scan(&t.ID, &t.Title, &t.Author, &t.Forum, &t.Message, &t.Votes, &t.Slug, &t.Created, &t.Locked, &t.Modified)
+				return &core.DumpRecord{Type: core.DumpThread, Thread: t, Locked: t.Locked, Modified: &t.Modified}, err
+			}, write); err != nil {
+			return err
+		}
+
+		if err := exportRows(ctx, tx, "SELECT id, parent, author, message, isEdited, forum, thread, created, modified FROM Posts ORDER BY id;",
+			func(rows pgx.Rows) (*core.DumpRecord, error) {
+				p := &core.Post{}
+				err := rows.Scan(&p.ID, &p.Parent, &p.Author, &p.Message, &p.IsEdited, &p.Forum, &p.Thread, &p.Created, &p.Modified)
+				return &core.DumpRecord{Type: core.DumpPost, Post: p, Modified: &p.Modified}, err
+			}, write); err != nil {
+			return err
+		}
+
+		if err := exportRows(ctx, tx, "SELECT nickname, thread, voice FROM Votes ORDER BY thread, nickname;",
+			func(rows pgx.Rows) (*core.DumpRecord, error) {
+				v := &core.Vote{}
+				err := rows.Scan(&v.Nickname, &v.ThreadID, &v.Voice)
+				return &core.DumpRecord{Type: core.DumpVote, Vote: v}, err
+			}, write); err != nil {
+			return err
+		}
+
+		if err := exportRows(ctx, tx, "SELECT nickname, password_hash, updated FROM Credentials ORDER BY nickname;",
+			func(rows pgx.Rows) (*core.DumpRecord, error) {
+				c := &core.Credential{}
+				err := rows.Scan(&c.Nickname, &c.PasswordHash, &c.Updated)
+				return &core.DumpRecord{Type: core.DumpCredential, Credential: c}, err
+			}, write); err != nil {
+			return err
+		}
+
+		if err := exportRows(ctx, tx, "SELECT token_hash, nickname, name, created FROM ApiTokens ORDER BY nickname, created;",
+			func(rows pgx.Rows) (*core.DumpRecord, error) {
+				t := &core.APIToken{}
+				err := rows.Scan(&t.TokenHash, &t.Nickname, &t.Name, &t.Created)
+				return &core.DumpRecord{Type: core.DumpAPIToken, APIToken: t}, err
+			}, write); err != nil {
+			return err
+		}
+
+		return exportRows(ctx, tx, "SELECT forum, nickname, COALESCE(granted_by, ''), created FROM ForumModerators ORDER BY forum, nickname;",
+			func(rows pgx.Rows) (*core.DumpRecord, error) {
+				m := &core.Moderator{}
+				err := rows.Scan(&m.Forum, &m.Nickname, &m.GrantedBy, &m.Created)
+				return &core.DumpRecord{Type: core.DumpModerator, Moderator: m}, err
+			}, write)
+	})
+}
+
+func exportRows(ctx context.Context, tx pgx.Tx, query string, scan func(rows pgx.Rows) (*core.DumpRecord, error), write func(record *core.DumpRecord) error) error {
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		if err := write(record); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Import inserts the records produced by Export in a single transaction.
+// Counters are left to the triggers, so thread votes and forum totals are
+// rebuilt from the imported rows rather than copied. Modification times are
+// kept, so that ETags and Last-Modified survive a restore.
+func (repo *serviceRepositoryImpl) Import(ctx context.Context, next func() (*core.DumpRecord, error)) (int64, error) {
+	var imported int64
+	err := repo.dbConn.BeginFunc(ctx, func(tx pgx.Tx) error {
+		// Imported votes update their threads, which touches them, so thread
+		// modification times are restored once the votes are in.
+		var threadIDs []int64
+		var threadsModified []time.Time
+		for {
+			record, err := next()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return err
+			}
+			if err := importRecord(ctx, tx, record); err != nil {
+				return fmt.Errorf("record %d (%s): %w", imported+1, record.Type, err)
+			}
+			if record.Type == core.DumpThread && record.Modified != nil {
+				threadIDs = append(threadIDs, record.Thread.ID)
+				threadsModified = append(threadsModified, *record.Modified)
+			}
+			imported++
+		}
+
+		if len(threadIDs) > 0 {
+			if _, err := tx.Exec(ctx, "ALTER TABLE Threads DISABLE TRIGGER touch_thread;"); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(ctx,
+				`UPDATE Threads t SET modified = m.modified
+					FROM unnest($1::bigint[], $2::timestamptz[]) AS m(id, modified) WHERE t.id = m.id;`,
+				threadIDs, threadsModified); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(ctx, "ALTER TABLE Threads ENABLE TRIGGER touch_thread;"); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Exec(ctx,
+			`SELECT setval(pg_get_serial_sequence('threads', 'id'), COALESCE((SELECT MAX(id) FROM Threads), 0) + 1, false),
+				setval(pg_get_serial_sequence('posts', 'id'), COALESCE((SELECT MAX(id) FROM Posts), 0) + 1, false);`)
+		return err
+	})
+	return imported, err
+}
+
+func importRecord(ctx context.Context, tx pgx.Tx, record *core.DumpRecord) error {
+	var err error
+	switch {
+	case record.Type == core.DumpUser && record.User != nil:
+		u := record.User
+		_, err = tx.Exec(ctx,
+			"INSERT INTO Users (nickname, fullname, about, email, banned, admin) VALUES ($1, $2, $3, $4, $5, $6);",
+			u.Nickname, u.Fullname, u.About, u.Email, record.Banned, record.Admin)
+	case record.Type == core.DumpForum && record.Forum != nil:
+		f := record.Forum
+		_, err = tx.Exec(ctx,
+			`INSERT INTO Forums (title, "user", slug) VALUES ($1, $2, $3);`,
+			f.Title, f.User, f.Slug)
+	case record.Type == core.DumpThread && record.Thread != nil:
+		t := record.Thread
+		_, err = tx.Exec(ctx,
+			"INSERT INTO Threads (id, title, author, forum, message, slug, created, locked, modified) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, COALESCE($9, now()));",
+			t.ID, t.Title, t.Author, t.Forum, t.Message, t.Slug, t.Created, record.Locked, record.Modified)
+	case record.Type == core.DumpPost && record.Post != nil:
+		p := record.Post
+		_, err = tx.Exec(ctx,
+			"INSERT INTO Posts (id, parent, author, message, isEdited, forum, thread, created, modified) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, COALESCE($9, now()));",
+			p.ID, p.Parent, p.Author, p.Message, p.IsEdited, p.Forum, p.Thread, p.Created, record.Modified)
+	case record.Type == core.DumpVote && record.Vote != nil:
+		v := record.Vote
+		_, err = tx.Exec(ctx,
+			"INSERT INTO Votes (nickname, thread, voice) VALUES ($1, $2, $3);",
+			v.Nickname, v.ThreadID, v.Voice)
+	case record.Type == core.DumpCredential && record.Credential != nil:
+		c := record.Credential
+		_, err = tx.Exec(ctx,
+			"INSERT INTO Credentials (nickname, password_hash, updated) VALUES ($1, $2, $3);",
+			c.Nickname, c.PasswordHash, c.Updated)
+	case record.Type == core.DumpAPIToken && record.APIToken != nil:
+		t := record.APIToken
+		_, err = tx.Exec(ctx,
+			"INSERT INTO ApiTokens (token_hash, nickname, name, created) VALUES ($1, $2, $3, $4);",
+			t.TokenHash, t.Nickname, t.Name, t.Created)
+	case record.Type == core.DumpModerator && record.Moderator != nil:
+		m := record.Moderator
+		_, err = tx.Exec(ctx,
+			"INSERT INTO ForumModerators (forum, nickname, granted_by, created) VALUES ($1, $2, NULLIF($3, ''), $4);",
+			m.Forum, m.Nickname, m.GrantedBy, m.Created)
+	default:
+		err = fmt.Errorf("malformed record of type %q", record.Type)
+	}
+	return err
+}
+
 func NewServiceRepository(dbConn *pgxpool.Pool) *serviceRepositoryImpl {
-	return &serviceRepositoryImpl{dbConn: dbConn}
+	return &serviceRepositoryImpl{dbConn: conn{pool: dbConn}}
 }
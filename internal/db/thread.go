@@ -11,10 +11,12 @@ type ThreadRepository interface {
 	GetThreadByID(ctx context.Context, id int64) (*core.Thread, error)
 	GetThreadBySlug(ctx context.Context, slug string) (*core.Thread, error)
 	UpdateThreadByID(ctx context.Context, id int64, title string, message string) (*core.Thread, error)
+	SetThreadLocked(ctx context.Context, id int64, locked bool) (*core.Thread, error)
+	GetThreadsActivity(ctx context.Context, ids []int64) ([]*core.ThreadActivity, error)
 }
 
 type threadRepositoryImpl struct {
-	dbConn *pgxpool.Pool
+	dbConn conn
 }
 
 func (repo *threadRepositoryImpl) CreateThread(ctx context.Context, thread *core.Thread) (*core.Thread, error) {
@@ -29,25 +31,52 @@ func (repo *threadRepositoryImpl) CreateThread(ctx context.Context, thread *core
 func (repo *threadRepositoryImpl) UpdateThreadByID(ctx context.Context, id int64, title string, message string) (*core.Thread, error) {
 	t := &core.Thread{}
 	err := repo.dbConn.QueryRow(ctx,
-		"UPDATE Threads SET title = $2, message = $3 WHERE id = $1 RETURNING id, title, author, forum, message, votes, slug, created;",
-		id, title, message).Scan(&t.ID, &t.Title, &t.Author, &t.Forum, &t.Message, &t.Votes, &t.Slug, &t.Created)
+		"UPDATE Threads SET title = $2, message = $3 WHERE id = $1 RETURNING id, title, author, forum, message, votes, slug, created, locked;",
+		id, title, message).Scan(&t.ID, &t.Title, &t.Author, &t.Forum, &t.Message, &t.Votes, &t.Slug, &t.Created, &t.Locked)
+	return t, err
+}
+
+func (repo *threadRepositoryImpl) SetThreadLocked(ctx context.Context, id int64, locked bool) (*core.Thread, error) {
+	t := &core.Thread{}
+	err := repo.dbConn.QueryRow(ctx,
+		"UPDATE Threads SET locked = $2 WHERE id = $1 RETURNING id, title, author, forum, message, votes, slug, created, locked;",
+		id, locked).Scan(&t.ID, &t.Title, &t.Author, &t.Forum, &t.Message, &t.Votes, &t.Slug, &t.Created, &t.Locked)
 	return t, err
 }
 
 func (repo *threadRepositoryImpl) GetThreadByID(ctx context.Context, id int64) (*core.Thread, error) {
 	t := &core.Thread{}
 	err := repo.dbConn.QueryRow(ctx,
-		"SELECT id, title, author, forum, message, votes, slug, created FROM Threads WHERE id = $1;", id).Scan(&t.ID, &t.Title, &t.Author, &t.Forum, &t.Message, &t.Votes, &t.Slug, &t.Created)
+		"SELECT id, title, author, forum, message, votes, slug, created, modified, locked FROM Threads WHERE id = $1;", id).Scan(&t.ID, &t.Title, &t.Author, &t.Forum, &t.Message, &t.Votes, &t.Slug, &t.Created, &t.Modified, &t.Locked)
 	return t, err
 }
 
 func (repo *threadRepositoryImpl) GetThreadBySlug(ctx context.Context, slug string) (*core.Thread, error) {
 	t := &core.Thread{}
 	err := repo.dbConn.QueryRow(ctx,
-		"SELECT id, title, author, forum, message, votes, slug, created FROM Threads WHERE slug = $1;", slug).Scan(&t.ID, &t.Title, &t.Author, &t.Forum, &t.Message, &t.Votes, &t.Slug, &t.Created)
+		"SELECT id, title, author, forum, message, votes, slug, created, modified, locked FROM Threads WHERE slug = $1;", slug).Scan(&t.ID, &t.Title, &t.Author, &t.Forum, &t.Message, &t.Votes, &t.Slug, &t.Created, &t.Modified, &t.Locked)
 	return t, err
 }
 
+func (repo *threadRepositoryImpl) GetThreadsActivity(ctx context.Context, ids []int64) ([]*core.ThreadActivity, error) {
+	rows, err := repo.dbConn.Query(ctx,
+		"SELECT id, posts, GREATEST(modified, last_post) FROM Threads WHERE id = ANY($1::bigint[]);", ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	activity := make([]*core.ThreadActivity, 0, len(ids))
+	for rows.Next() {
+		a := &core.ThreadActivity{}
+		if err := rows.Scan(&a.ID, &a.Posts, &a.LastActivity); err != nil {
+			return nil, err
+		}
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
+}
+
 func NewThreadRepository(dbConn *pgxpool.Pool) *threadRepositoryImpl {
-	return &threadRepositoryImpl{dbConn: dbConn}
+	return &threadRepositoryImpl{dbConn: conn{pool: dbConn}}
 }
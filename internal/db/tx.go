@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type txKey struct{}
+
+// txState is the transaction shared by the repository calls made with a
+// context, and the callbacks waiting for it to commit.
+type txState struct {
+	tx pgx.Tx
+
+	mu          sync.Mutex
+	afterCommit []func()
+}
+
+// Transactor groups repository calls into transactions.
+type Transactor interface {
+	// InTx runs fn with a context whose repository calls share one
+	// transaction, committed if fn returns nil and rolled back otherwise.
+	// Calls inside fn must not run concurrently. Nested calls join the
+	// outer transaction.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type transactorImpl struct {
+	dbConn conn
+}
+
+func (t *transactorImpl) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	state := &txState{}
+	err := t.dbConn.BeginFunc(ctx, func(tx pgx.Tx) error {
+		state.tx = tx
+		return fn(context.WithValue(ctx, txKey{}, state))
+	})
+	if err != nil {
+		return err
+	}
+	for _, callback := range state.afterCommit {
+		callback()
+	}
+	return nil
+}
+
+func NewTransactor(dbConn *pgxpool.Pool) Transactor {
+	return &transactorImpl{dbConn: conn{pool: dbConn}}
+}
+
+// AfterCommit runs fn once the transaction of ctx commits, or right away
+// when ctx carries none. Callbacks of rolled back transactions are dropped.
+func AfterCommit(ctx context.Context, fn func()) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		fn()
+		return
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.afterCommit = append(state.afterCommit, fn)
+}
+
+// conn runs the statements of a repository on the transaction of the
+// context, if there is one, and on the pool otherwise.
+type conn struct {
+	pool *pgxpool.Pool
+}
+
+func txFrom(ctx context.Context) pgx.Tx {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return nil
+}
+
+func (c conn) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	if tx := txFrom(ctx); tx != nil {
+		return tx.Exec(ctx, sql, args...)
+	}
+	return c.pool.Exec(ctx, sql, args...)
+}
+
+func (c conn) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	if tx := txFrom(ctx); tx != nil {
+		return tx.Query(ctx, sql, args...)
+	}
+	return c.pool.Query(ctx, sql, args...)
+}
+
+func (c conn) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	if tx := txFrom(ctx); tx != nil {
+		return tx.QueryRow(ctx, sql, args...)
+	}
+	return c.pool.QueryRow(ctx, sql, args...)
+}
+
+// BeginFunc runs f in a savepoint of the transaction of ctx, if there is
+// one, and in a transaction of its own otherwise.
+func (c conn) BeginFunc(ctx context.Context, f func(pgx.Tx) error) error {
+	if tx := txFrom(ctx); tx != nil {
+		return tx.BeginFunc(ctx, f)
+	}
+	return c.pool.BeginFunc(ctx, f)
+}
+
+// BeginTxFunc is BeginFunc with options, which can't be changed inside a
+// transaction that is already running.
+func (c conn) BeginTxFunc(ctx context.Context, options pgx.TxOptions, f func(pgx.Tx) error) error {
+	if txFrom(ctx) != nil {
+		return errors.New("transaction options can't be set inside a running transaction")
+	}
+	return c.pool.BeginTxFunc(ctx, options, f)
+}
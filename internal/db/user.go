@@ -2,8 +2,11 @@ package db
 
 import (
 	"context"
+	"errors"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rinatkh/db_forum/internal/model/core"
+
+	"github.com/jackc/pgx/v5"
 )
 
 type UserRepository interface {
@@ -11,11 +14,15 @@ type UserRepository interface {
 	GetUserByEmail(ctx context.Context, email string) (*core.User, error)
 	GetUserByNickname(ctx context.Context, nickname string) (*core.User, error)
 	GetUsersByEmailOrNickname(ctx context.Context, email, nickname string) ([]*core.User, error)
+	GetUsersByNicknames(ctx context.Context, nicknames []string) ([]*core.User, error)
 	EditUser(ctx context.Context, user *core.User) (*core.User, error)
+	SetUserBanned(ctx context.Context, nickname string, banned bool) (*core.User, error)
+	SetUserAdmin(ctx context.Context, nickname string, admin bool) (*core.User, error)
+	FindBannedUser(ctx context.Context, nicknames []string) (string, error)
 }
 
 type userRepositoryImpl struct {
-	dbConn *pgxpool.Pool
+	dbConn conn
 }
 
 func (repo *userRepositoryImpl) CreateUser(ctx context.Context, user *core.User) error {
@@ -35,7 +42,7 @@ func (repo *userRepositoryImpl) GetUserByEmail(ctx context.Context, email string
 func (repo *userRepositoryImpl) GetUserByNickname(ctx context.Context, nickname string) (*core.User, error) {
 	user := &core.User{}
 	err := repo.dbConn.QueryRow(ctx,
-		"SELECT nickname, fullname, about, email FROM Users where nickname = $1;", nickname).Scan(&user.Nickname, &user.Fullname, &user.About, &user.Email)
+		"SELECT nickname, fullname, about, email, banned FROM Users where nickname = $1;", nickname).Scan(&user.Nickname, &user.Fullname, &user.About, &user.Email, &user.Banned)
 	return user, err
 }
 
@@ -49,8 +56,43 @@ func (repo *userRepositoryImpl) EditUser(ctx context.Context, user *core.User) (
 	return updatedUser, nil
 }
 
+func (repo *userRepositoryImpl) SetUserBanned(ctx context.Context, nickname string, banned bool) (*core.User, error) {
+	user := &core.User{}
+	err := repo.dbConn.QueryRow(ctx,
+		"UPDATE Users SET banned = $2 WHERE nickname = $1 RETURNING nickname, fullname, about, email, banned;",
+		nickname, banned).Scan(&user.Nickname, &user.Fullname, &user.About, &user.Email, &user.Banned)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (repo *userRepositoryImpl) SetUserAdmin(ctx context.Context, nickname string, admin bool) (*core.User, error) {
+	user := &core.User{}
+	err := repo.dbConn.QueryRow(ctx,
+		"UPDATE Users SET admin = $2 WHERE nickname = $1 RETURNING nickname, fullname, about, email, banned;",
+		nickname, admin).Scan(&user.Nickname, &user.Fullname, &user.About, &user.Email, &user.Banned)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (repo *userRepositoryImpl) FindBannedUser(ctx context.Context, nicknames []string) (string, error) {
+	var nickname string
+	err := repo.dbConn.QueryRow(ctx,
+		"SELECT nickname FROM Users WHERE nickname = ANY($1::citext[]) AND banned LIMIT 1;", nicknames).Scan(&nickname)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return nickname, nil
+}
+
 func NewUserRepository(dbConn *pgxpool.Pool) *userRepositoryImpl {
-	return &userRepositoryImpl{dbConn: dbConn}
+	return &userRepositoryImpl{dbConn: conn{pool: dbConn}}
 }
 
 func (repo *userRepositoryImpl) GetUsersByEmailOrNickname(ctx context.Context, email, nickname string) ([]*core.User, error) {
@@ -73,3 +115,23 @@ func (repo *userRepositoryImpl) GetUsersByEmailOrNickname(ctx context.Context, e
 
 	return users, nil
 }
+
+func (repo *userRepositoryImpl) GetUsersByNicknames(ctx context.Context, nicknames []string) ([]*core.User, error) {
+	rows, err := repo.dbConn.Query(ctx,
+		"SELECT nickname, fullname, about, email, banned FROM Users WHERE nickname = ANY($1::citext[]);",
+		nicknames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*core.User, 0, len(nicknames))
+	for rows.Next() {
+		u := &core.User{}
+		if err := rows.Scan(&u.Nickname, &u.Fullname, &u.About, &u.Email, &u.Banned); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
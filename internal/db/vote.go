@@ -16,7 +16,7 @@ type VotesRepository interface {
 }
 
 type votesRepositoryImpl struct {
-	dbConn *pgxpool.Pool
+	dbConn conn
 }
 
 func (repo *votesRepositoryImpl) VoteExists(ctx context.Context, nickname string, threadID int64) (bool, error) {
@@ -44,7 +44,7 @@ func (repo *votesRepositoryImpl) EditVote(ctx context.Context, threadID int64, n
 }
 
 func NewVotesRepository(dbConn *pgxpool.Pool) *votesRepositoryImpl {
-	return &votesRepositoryImpl{dbConn: dbConn}
+	return &votesRepositoryImpl{dbConn: conn{pool: dbConn}}
 }
 
 func (repo *votesRepositoryImpl) CreateVote(ctx context.Context, vote *core.Vote) error {
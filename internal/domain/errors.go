@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Kind int
+
+const (
+	KindNotFound Kind = iota + 1
+	KindConflict
+	KindValidation
+	KindForbidden
+	KindUnauthorized
+	KindRateLimited
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindValidation:
+		return "validation"
+	case KindForbidden:
+		return "forbidden"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindRateLimited:
+		return "rate_limited"
+	default:
+		return "unknown"
+	}
+}
+
+// Error is an expected failure of a domain operation. Entity and Key describe
+// the object the operation was about, e.g. "user" and the nickname.
+type Error struct {
+	Kind    Kind
+	Entity  string
+	Key     string
+	Message string
+	// Existing is the already stored entity a Conflict refers to; the swagger
+	// contract returns it instead of an error message for some endpoints.
+	Existing interface{}
+	// Fields lists the offending request fields of a Validation error.
+	Fields []FieldError
+	// RetryAfter is how long a RateLimited caller should wait.
+	RetryAfter time.Duration
+}
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func NotFound(entity, key, format string, args ...interface{}) *Error {
+	return &Error{Kind: KindNotFound, Entity: entity, Key: key, Message: fmt.Sprintf(format, args...)}
+}
+
+func Conflict(entity, key string, existing interface{}, format string, args ...interface{}) *Error {
+	return &Error{Kind: KindConflict, Entity: entity, Key: key, Existing: existing, Message: fmt.Sprintf(format, args...)}
+}
+
+func Validation(entity, key, format string, args ...interface{}) *Error {
+	return &Error{Kind: KindValidation, Entity: entity, Key: key, Message: fmt.Sprintf(format, args...)}
+}
+
+// InvalidFields reports a request that failed validation of the given fields.
+func InvalidFields(fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Entity: "request", Message: "request validation failed", Fields: fields}
+}
+
+func Forbidden(entity, key, format string, args ...interface{}) *Error {
+	return &Error{Kind: KindForbidden, Entity: entity, Key: key, Message: fmt.Sprintf(format, args...)}
+}
+
+// Unauthorized reports a request that needs credentials it did not present.
+func Unauthorized(format string, args ...interface{}) *Error {
+	return &Error{Kind: KindUnauthorized, Entity: "request", Message: fmt.Sprintf(format, args...)}
+}
+
+// RateLimited reports a caller that exceeded its budget and may retry after
+// the given delay.
+func RateLimited(retryAfter time.Duration, format string, args ...interface{}) *Error {
+	return &Error{Kind: KindRateLimited, Entity: "request", RetryAfter: retryAfter, Message: fmt.Sprintf(format, args...)}
+}
+
+// As returns the domain error wrapped in err, if any.
+func As(err error) (*Error, bool) {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr, true
+	}
+	return nil, false
+}
+
+// IsKind reports whether err is a domain error of the given kind.
+func IsKind(err error, kind Kind) bool {
+	domainErr, ok := As(err)
+	return ok && domainErr.Kind == kind
+}
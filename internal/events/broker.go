@@ -0,0 +1,143 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/rinatkh/db_forum/internal/model/core"
+)
+
+// Type names what changed in a thread.
+type Type string
+
+const (
+	PostCreated  Type = "post_created"
+	PostEdited   Type = "post_edited"
+	VotesChanged Type = "votes_changed"
+)
+
+// Event is a change of a thread pushed to its subscribers. Post is set for
+// post events and Votes, the new vote count, for vote events.
+type Event struct {
+	Type   Type       `json:"type"`
+	Thread int64      `json:"thread"`
+	Post   *core.Post `json:"post,omitempty"`
+	Votes  *int64     `json:"votes,omitempty"`
+}
+
+// Publisher accepts the changes made by the services.
+type Publisher interface {
+	Publish(event Event)
+}
+
+type discard struct{}
+
+func (discard) Publish(Event) {}
+
+// Discard drops every event, for callers nobody subscribes to and for
+// deployments where Postgres publishes the changes instead.
+var Discard Publisher = discard{}
+
+// Subscription delivers the events of one thread. C is closed when the
+// subscriber falls too far behind or the broker shuts down.
+type Subscription struct {
+	C <-chan Event
+
+	broker *brokerImpl
+	thread int64
+	ch     chan Event
+}
+
+// Close stops the delivery of events.
+func (s *Subscription) Close() {
+	s.broker.remove(s)
+}
+
+// Broker fans the events of each thread out to its subscribers.
+type Broker interface {
+	Publisher
+	Subscribe(thread int64) *Subscription
+	// Subscribed reports whether anyone listens to the events of thread.
+	Subscribed(thread int64) bool
+	// Close ends every subscription; later subscriptions are closed at once.
+	Close()
+}
+
+type brokerImpl struct {
+	mu     sync.RWMutex
+	buffer int
+	subs   map[int64]map[*Subscription]struct{}
+	closed bool
+}
+
+func (b *brokerImpl) Publish(event Event) {
+	b.mu.RLock()
+	var lagging []*Subscription
+	for sub := range b.subs[event.Thread] {
+		select {
+		case sub.ch <- event:
+		default:
+			lagging = append(lagging, sub)
+		}
+	}
+	b.mu.RUnlock()
+
+	// Subscribers that can't keep up are cut off rather than slowing down
+	// the writers; they reconnect and catch up through the listings.
+	for _, sub := range lagging {
+		b.remove(sub)
+	}
+}
+
+func (b *brokerImpl) Subscribe(thread int64) *Subscription {
+	ch := make(chan Event, b.buffer)
+	sub := &Subscription{C: ch, broker: b, thread: thread, ch: ch}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return sub
+	}
+	if b.subs[thread] == nil {
+		b.subs[thread] = make(map[*Subscription]struct{})
+	}
+	b.subs[thread][sub] = struct{}{}
+	return sub
+}
+
+func (b *brokerImpl) Subscribed(thread int64) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs[thread]) > 0
+}
+
+func (b *brokerImpl) remove(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[sub.thread][sub]; !ok {
+		return
+	}
+	delete(b.subs[sub.thread], sub)
+	if len(b.subs[sub.thread]) == 0 {
+		delete(b.subs, sub.thread)
+	}
+	close(sub.ch)
+}
+
+func (b *brokerImpl) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for _, subs := range b.subs {
+		for sub := range subs {
+			close(sub.ch)
+		}
+	}
+	b.subs = make(map[int64]map[*Subscription]struct{})
+}
+
+// NewBroker returns a broker that buffers up to buffer events per
+// subscriber.
+func NewBroker(buffer int) Broker {
+	return &brokerImpl{buffer: buffer, subs: make(map[int64]map[*Subscription]struct{})}
+}
@@ -0,0 +1,108 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rinatkh/db_forum/internal/model/core"
+	"github.com/sirupsen/logrus"
+)
+
+// Channel is the Postgres notification channel the triggers of the Posts and
+// Threads tables publish to, on connections with forum.notify set to on.
+const Channel = "forum_events"
+
+// NotifySetting enables the triggers for the sessions of the connections
+// it is set on.
+const NotifySetting = "forum.notify"
+
+const maxBackoff = 30 * time.Second
+
+// notification is the payload of the triggers. Posts are sent by id, as
+// their messages may not fit into a notification.
+type notification struct {
+	Type   Type  `json:"type"`
+	Thread int64 `json:"thread"`
+	ID     int64 `json:"id"`
+	Votes  int64 `json:"votes"`
+}
+
+// PostGetter loads the posts named by notifications.
+type PostGetter interface {
+	GetPostByID(ctx context.Context, id int64) (*core.Post, error)
+}
+
+// Listener publishes the changes Postgres notifies of to a broker, so that
+// the subscribers of every instance learn about the changes made through
+// any of them.
+type Listener struct {
+	log    *logrus.Entry
+	config *pgx.ConnConfig
+	posts  PostGetter
+	broker Broker
+}
+
+// Run listens until ctx is done, reconnecting after failures.
+func (l *Listener) Run(ctx context.Context) {
+	backoff := time.Second
+	for {
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		l.log.Warnf("listening for %s failed, retrying in %s: %s", Channel, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	conn, err := pgx.ConnectConfig(ctx, l.config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+	l.log.Infof("listening for %s", Channel)
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var payload notification
+		if err := json.Unmarshal([]byte(n.Payload), &payload); err != nil {
+			l.log.Errorf("malformed %s notification %q: %s", Channel, n.Payload, err)
+			continue
+		}
+		if !l.broker.Subscribed(payload.Thread) {
+			continue
+		}
+		event := Event{Type: payload.Type, Thread: payload.Thread}
+		switch payload.Type {
+		case PostCreated, PostEdited:
+			if event.Post, err = l.posts.GetPostByID(ctx, payload.ID); err != nil {
+				l.log.Errorf("unable to load post %d: %s", payload.ID, err)
+				continue
+			}
+		case VotesChanged:
+			event.Votes = &payload.Votes
+		}
+		l.broker.Publish(event)
+	}
+}
+
+// NewListener listens on a connection of its own, made from config.
+func NewListener(log *logrus.Entry, config *pgx.ConnConfig, posts PostGetter, broker Broker) *Listener {
+	return &Listener{log: log, config: config, posts: posts, broker: broker}
+}
@@ -0,0 +1,44 @@
+package graphql
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/rinatkh/db_forum/internal/domain"
+)
+
+// costBudget bounds how many rows the listings of one request may ask for.
+// Nested listings run once per parent, so their cost multiplies with every
+// level; listings past the budget fail instead of running.
+type costBudget struct {
+	max  int64
+	used int64
+}
+
+// charge books rows against the budget and reports whether it still holds.
+func (b *costBudget) charge(rows int64) bool {
+	return atomic.AddInt64(&b.used, rows) <= b.max
+}
+
+type costKey struct{}
+
+func withCost(ctx context.Context, max int) context.Context {
+	if max <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, costKey{}, &costBudget{max: int64(max)})
+}
+
+// charge books a listing of up to limit rows against the budget of the
+// request, if it has one.
+func (r *resolver) charge(ctx context.Context, limit int32) error {
+	budget, ok := ctx.Value(costKey{}).(*costBudget)
+	if limit < 0 {
+		// Left to request validation, without crediting the budget.
+		limit = 0
+	}
+	if !ok || budget.charge(int64(limit)) {
+		return nil
+	}
+	return r.fail(ctx, domain.Validation("query", "", "Query asks for more than %d rows, request fewer or smaller listings", budget.max))
+}
@@ -0,0 +1,146 @@
+package graphql
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/model/core"
+	"github.com/rinatkh/db_forum/internal/service"
+)
+
+// loader batches lookups by key for the duration of one request. Keys queued
+// with Prime are fetched together with the first Load that misses, and every
+// key is fetched at most once, however many resolvers ask for it.
+type loader[K comparable, V any] struct {
+	fetch   func(ctx context.Context, keys []K) (map[K]V, error)
+	mu      sync.Mutex
+	queued  []K
+	entries map[K]*loaderEntry[V]
+}
+
+type loaderEntry[V any] struct {
+	done  chan struct{}
+	value V
+	found bool
+	err   error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, entries: make(map[K]*loaderEntry[V])}
+}
+
+// Prime queues keys that are about to be loaded, typically by the items of a
+// list that was just resolved.
+func (l *loader[K, V]) Prime(keys ...K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		if _, ok := l.entries[key]; !ok {
+			l.queued = append(l.queued, key)
+		}
+	}
+}
+
+// Load returns the value of key and whether it exists.
+func (l *loader[K, V]) Load(ctx context.Context, key K) (V, bool, error) {
+	l.mu.Lock()
+	entry, ok := l.entries[key]
+	if !ok {
+		batch := append(l.queued, key)
+		l.queued = nil
+		keys := make([]K, 0, len(batch))
+		pending := make([]*loaderEntry[V], 0, len(batch))
+		for _, k := range batch {
+			if _, ok := l.entries[k]; !ok {
+				e := &loaderEntry[V]{done: make(chan struct{})}
+				l.entries[k] = e
+				keys = append(keys, k)
+				pending = append(pending, e)
+			}
+		}
+		entry = l.entries[key]
+		l.mu.Unlock()
+
+		values, err := l.fetch(ctx, keys)
+		for n, e := range pending {
+			e.value, e.found = values[keys[n]]
+			e.err = err
+			close(e.done)
+		}
+	} else {
+		l.mu.Unlock()
+	}
+
+	select {
+	case <-entry.done:
+		return entry.value, entry.found, entry.err
+	case <-ctx.Done():
+		var zero V
+		return zero, false, ctx.Err()
+	}
+}
+
+// loaders are the per-request loaders of the entities other entities refer
+// to. Nicknames and slugs are case-insensitive, so they are keyed in lower
+// case.
+type loaders struct {
+	users   *loader[string, *core.User]
+	forums  *loader[string, *core.Forum]
+	threads *loader[int64, *core.Thread]
+}
+
+func newLoaders(registry *service.Registry) *loaders {
+	return &loaders{
+		users: newLoader(func(ctx context.Context, nicknames []string) (map[string]*core.User, error) {
+			users, err := registry.UserService.GetUsers(ctx, nicknames)
+			if err != nil {
+				return nil, err
+			}
+			byNickname := make(map[string]*core.User, len(users))
+			for _, user := range users {
+				byNickname[strings.ToLower(user.Nickname)] = user
+			}
+			return byNickname, nil
+		}),
+		forums: newLoader(func(ctx context.Context, slugs []string) (map[string]*core.Forum, error) {
+			forums, err := registry.ForumService.GetForums(ctx, slugs)
+			if err != nil {
+				return nil, err
+			}
+			bySlug := make(map[string]*core.Forum, len(forums))
+			for _, forum := range forums {
+				bySlug[strings.ToLower(forum.Slug)] = forum
+			}
+			return bySlug, nil
+		}),
+		// Listed posts mostly share their thread, so threads are fetched one
+		// by one.
+		threads: newLoader(func(ctx context.Context, ids []int64) (map[int64]*core.Thread, error) {
+			threads := make(map[int64]*core.Thread, len(ids))
+			for _, id := range ids {
+				thread, err := registry.ThreadService.GetThread(ctx, strconv.FormatInt(id, 10))
+				if err != nil {
+					if _, ok := domain.As(err); ok {
+						continue
+					}
+					return nil, err
+				}
+				threads[id] = thread
+			}
+			return threads, nil
+		}),
+	}
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,417 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/logging"
+	"github.com/rinatkh/db_forum/internal/model/core"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	"github.com/rinatkh/db_forum/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+// Validator checks request DTOs the way the REST binder does.
+type Validator interface {
+	Validate(i interface{}) error
+}
+
+// resolver is the root of the schema and resolves both queries and
+// mutations through the service registry.
+type resolver struct {
+	log       *logrus.Entry
+	registry  *service.Registry
+	validator Validator
+}
+
+func (r *resolver) validate(request interface{}) error {
+	if r.validator == nil {
+		return nil
+	}
+	return r.validator.Validate(request)
+}
+
+// queryError exposes the kind of a domain error, and the offending fields of
+// a validation error, in the extensions of a GraphQL error.
+type queryError struct {
+	err *domain.Error
+}
+
+func (e *queryError) Error() string {
+	return e.err.Message
+}
+
+func (e *queryError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.err.Kind.String()}
+	if len(e.err.Fields) > 0 {
+		fields := make([]dto.FieldError, 0, len(e.err.Fields))
+		for _, field := range e.err.Fields {
+			fields = append(fields, dto.FieldError{Field: field.Field, Message: field.Message})
+		}
+		extensions["fields"] = fields
+	}
+	if e.err.RetryAfter > 0 {
+		extensions["retryAfter"] = e.err.RetryAfter.Seconds()
+	}
+	return extensions
+}
+
+// fail turns err into the error reported to the client. Unexpected errors
+// are logged and hidden, as the REST error handler does.
+func (r *resolver) fail(ctx context.Context, err error) error {
+	if domainErr, ok := domain.As(err); ok {
+		return &queryError{err: domainErr}
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return errors.New("request canceled")
+	}
+	logging.FromContext(ctx, r.log).Errorf("unexpected error in GraphQL resolver: %s", err)
+	return errors.New(http.StatusText(http.StatusInternalServerError))
+}
+
+func (r *resolver) loadUser(ctx context.Context, nickname string) (*userResolver, error) {
+	user, found, err := loadersFrom(ctx).users.Load(ctx, strings.ToLower(nickname))
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	if !found {
+		return nil, r.fail(ctx, domain.NotFound("user", nickname, "Can't find user by nickname: %s", nickname))
+	}
+	return &userResolver{user: user}, nil
+}
+
+func (r *resolver) loadForum(ctx context.Context, slug string) (*forumResolver, error) {
+	forum, found, err := loadersFrom(ctx).forums.Load(ctx, strings.ToLower(slug))
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	if !found {
+		return nil, r.fail(ctx, domain.NotFound("forum", slug, "Can't find forum with slug: %s", slug))
+	}
+	return &forumResolver{root: r, forum: forum}, nil
+}
+
+// threadResolvers wraps threads and queues their authors for one batched
+// lookup.
+func (r *resolver) threadResolvers(ctx context.Context, threads []*core.Thread) []*threadResolver {
+	resolvers := make([]*threadResolver, 0, len(threads))
+	authors := make([]string, 0, len(threads))
+	for _, thread := range threads {
+		resolvers = append(resolvers, &threadResolver{root: r, thread: thread})
+		authors = append(authors, strings.ToLower(thread.Author))
+	}
+	loadersFrom(ctx).users.Prime(authors...)
+	return resolvers
+}
+
+// postResolvers wraps posts and queues their authors for one batched lookup.
+func (r *resolver) postResolvers(ctx context.Context, posts []*core.Post) []*postResolver {
+	resolvers := make([]*postResolver, 0, len(posts))
+	authors := make([]string, 0, len(posts))
+	for _, post := range posts {
+		resolvers = append(resolvers, &postResolver{root: r, post: post})
+		authors = append(authors, strings.ToLower(post.Author))
+	}
+	loadersFrom(ctx).users.Prime(authors...)
+	return resolvers
+}
+
+// Queries answer null for entities that don't exist.
+
+func (r *resolver) User(ctx context.Context, args struct{ Nickname string }) (*userResolver, error) {
+	request := &dto.GetUserProfileRequest{Nickname: args.Nickname}
+	if err := r.validate(request); err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	user, err := r.registry.UserService.GetUserProfile(ctx, request)
+	if err != nil {
+		return nil, r.nullIfNotFound(ctx, err)
+	}
+	return &userResolver{user: user}, nil
+}
+
+func (r *resolver) Forum(ctx context.Context, args struct{ Slug string }) (*forumResolver, error) {
+	request := &dto.GetForumRequest{Slug: args.Slug}
+	if err := r.validate(request); err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	forum, err := r.registry.ForumService.GetForum(ctx, request)
+	if err != nil {
+		return nil, r.nullIfNotFound(ctx, err)
+	}
+	return &forumResolver{root: r, forum: forum}, nil
+}
+
+func (r *resolver) Thread(ctx context.Context, args struct{ SlugOrID string }) (*threadResolver, error) {
+	thread, err := r.registry.ThreadService.GetThread(ctx, args.SlugOrID)
+	if err != nil {
+		return nil, r.nullIfNotFound(ctx, err)
+	}
+	return &threadResolver{root: r, thread: thread}, nil
+}
+
+func (r *resolver) Post(ctx context.Context, args struct{ ID graphqlgo.ID }) (*postResolver, error) {
+	postID, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	details, err := r.registry.PostsService.GetPostDetails(ctx, &dto.GetPostDetailsRequest{ID: postID})
+	if err != nil {
+		return nil, r.nullIfNotFound(ctx, err)
+	}
+	return &postResolver{root: r, post: details.Post}, nil
+}
+
+func (r *resolver) nullIfNotFound(ctx context.Context, err error) error {
+	if domainErr, ok := domain.As(err); ok && domainErr.Kind == domain.KindNotFound {
+		return nil
+	}
+	return r.fail(ctx, err)
+}
+
+// Mutations mirror the REST write operations and share their validation.
+
+func (r *resolver) CreateUser(ctx context.Context, args struct {
+	Nickname string
+	Input    struct {
+		Fullname string
+		About    *string
+		Email    string
+		Password *string
+	}
+}) (*userResolver, error) {
+	request := &dto.CreateUserRequest{
+		Nickname: args.Nickname,
+		Fullname: args.Input.Fullname,
+		About:    deref(args.Input.About),
+		Email:    args.Input.Email,
+		Password: deref(args.Input.Password),
+	}
+	if err := r.validate(request); err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	user, err := r.registry.UserService.CreateUser(ctx, request)
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	return &userResolver{user: user}, nil
+}
+
+func (r *resolver) UpdateUser(ctx context.Context, args struct {
+	Nickname string
+	Input    struct {
+		Fullname *string
+		About    *string
+		Email    *string
+	}
+}) (*userResolver, error) {
+	request := &dto.EditUserProfileRequest{
+		Nickname: args.Nickname,
+		Fullname: deref(args.Input.Fullname),
+		About:    deref(args.Input.About),
+		Email:    deref(args.Input.Email),
+	}
+	if err := r.validate(request); err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	user, err := r.registry.UserService.EditUserProfile(ctx, request)
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	return &userResolver{user: user}, nil
+}
+
+func (r *resolver) BanUser(ctx context.Context, args struct {
+	Nickname string
+	Banned   bool
+}) (*userResolver, error) {
+	request := &dto.BanUserRequest{Nickname: args.Nickname, Banned: args.Banned}
+	if err := r.validate(request); err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	user, err := r.registry.UserService.BanUser(ctx, request)
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	return &userResolver{user: user}, nil
+}
+
+func (r *resolver) CreateForum(ctx context.Context, args struct {
+	Input struct {
+		Slug  string
+		Title string
+		User  string
+	}
+}) (*forumResolver, error) {
+	request := &dto.CreateForumRequest{Slug: args.Input.Slug, Title: args.Input.Title, User: args.Input.User}
+	if err := r.validate(request); err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	forum, err := r.registry.ForumService.CreateForum(ctx, request)
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	return &forumResolver{root: r, forum: forum}, nil
+}
+
+type moderatorArgs struct {
+	Forum    string
+	Nickname string
+}
+
+func (r *resolver) AddModerator(ctx context.Context, args moderatorArgs) ([]*userResolver, error) {
+	request := &dto.ModeratorRequest{Slug: args.Forum, Nickname: args.Nickname}
+	if err := r.validate(request); err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	users, err := r.registry.ForumService.AddModerator(ctx, request)
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	return userResolvers(users), nil
+}
+
+func (r *resolver) RemoveModerator(ctx context.Context, args moderatorArgs) ([]*userResolver, error) {
+	request := &dto.ModeratorRequest{Slug: args.Forum, Nickname: args.Nickname}
+	if err := r.validate(request); err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	users, err := r.registry.ForumService.RemoveModerator(ctx, request)
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	return userResolvers(users), nil
+}
+
+func (r *resolver) CreateThread(ctx context.Context, args struct {
+	Forum string
+	Input struct {
+		Author  string
+		Title   string
+		Message string
+		Slug    *string
+		Created *graphqlgo.Time
+	}
+}) (*threadResolver, error) {
+	request := &dto.CreateThreadRequest{
+		Forum:   args.Forum,
+		Author:  args.Input.Author,
+		Title:   args.Input.Title,
+		Message: args.Input.Message,
+		Slug:    deref(args.Input.Slug),
+	}
+	if args.Input.Created != nil {
+		request.Created = args.Input.Created.Time
+	}
+	if err := r.validate(request); err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	thread, err := r.registry.ThreadService.CreateThread(ctx, request)
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	return &threadResolver{root: r, thread: thread}, nil
+}
+
+func (r *resolver) UpdateThread(ctx context.Context, args struct {
+	SlugOrID string
+	Input    struct {
+		Title   *string
+		Message *string
+	}
+}) (*threadResolver, error) {
+	request := &dto.EditThreadRequest{Title: deref(args.Input.Title), Message: deref(args.Input.Message)}
+	thread, err := r.registry.ThreadService.EditThread(ctx, args.SlugOrID, request)
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	return &threadResolver{root: r, thread: thread}, nil
+}
+
+func (r *resolver) LockThread(ctx context.Context, args struct {
+	SlugOrID string
+	Locked   bool
+}) (*threadResolver, error) {
+	thread, err := r.registry.ThreadService.LockThread(ctx, args.SlugOrID, &dto.LockThreadRequest{Locked: args.Locked})
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	return &threadResolver{root: r, thread: thread}, nil
+}
+
+func (r *resolver) Vote(ctx context.Context, args struct {
+	SlugOrID string
+	Nickname string
+	Voice    int32
+}) (*voteResolver, error) {
+	request := &dto.EditVoteRequest{Nickname: args.Nickname, Voice: int64(args.Voice)}
+	if err := r.validate(request); err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	thread, err := r.registry.ThreadService.CountVote(ctx, args.SlugOrID, request)
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	return &voteResolver{root: r, nickname: request.Nickname, voice: request.Voice, thread: thread}, nil
+}
+
+func (r *resolver) CreatePosts(ctx context.Context, args struct {
+	SlugOrID string
+	Posts    []struct {
+		Author  string
+		Message string
+		Parent  *graphqlgo.ID
+	}
+}) ([]*postResolver, error) {
+	request := make([]*dto.Post, 0, len(args.Posts))
+	for n, post := range args.Posts {
+		item := &dto.Post{Author: post.Author, Message: post.Message}
+		if post.Parent != nil {
+			parent, err := parseID(fmt.Sprintf("[%d].parent", n), *post.Parent)
+			if err != nil {
+				return nil, r.fail(ctx, err)
+			}
+			item.Parent = parent
+		}
+		request = append(request, item)
+	}
+	if err := r.validate(request); err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	posts, err := r.registry.PostsService.CreatePosts(ctx, args.SlugOrID, request)
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	return r.postResolvers(ctx, posts), nil
+}
+
+func (r *resolver) UpdatePost(ctx context.Context, args struct {
+	ID      graphqlgo.ID
+	Message string
+}) (*postResolver, error) {
+	postID, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	request := &dto.EditPostRequest{ID: postID, Message: args.Message}
+	if err := r.validate(request); err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	post, err := r.registry.PostsService.EditPost(ctx, request)
+	if err != nil {
+		return nil, r.fail(ctx, err)
+	}
+	return &postResolver{root: r, post: post}, nil
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,81 @@
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"runtime/debug"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	graphqlotel "github.com/graph-gophers/graphql-go/trace/otel"
+	"github.com/rinatkh/db_forum/internal/logging"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	"github.com/rinatkh/db_forum/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed schema.graphql
+var schemaSource string
+
+// Options tune the execution of GraphQL requests.
+type Options struct {
+	// MaxDepth bounds the nesting of selections; zero leaves it unbounded.
+	MaxDepth int
+	// MaxCost bounds the rows all listings of a request may ask for, summed
+	// over their limits; zero leaves it unbounded.
+	MaxCost int
+	// MaxParallelism bounds the resolvers a request runs at once.
+	MaxParallelism int
+	Introspection  bool
+	Tracing        bool
+}
+
+// Executor runs GraphQL requests against the service registry.
+type Executor interface {
+	Exec(ctx context.Context, request *dto.GraphQLRequest) *graphqlgo.Response
+}
+
+type executorImpl struct {
+	schema   *graphqlgo.Schema
+	registry *service.Registry
+	maxCost  int
+}
+
+// Exec runs request with loaders and a cost budget of its own, so that
+// nothing is cached or counted across requests.
+func (e *executorImpl) Exec(ctx context.Context, request *dto.GraphQLRequest) *graphqlgo.Response {
+	ctx = withCost(withLoaders(ctx, newLoaders(e.registry)), e.maxCost)
+	return e.schema.Exec(ctx, request.Query, request.OperationName, request.Variables)
+}
+
+// panicLogger reports panics of resolvers through the request logger.
+type panicLogger struct {
+	log *logrus.Entry
+}
+
+func (l panicLogger) LogPanic(ctx context.Context, value interface{}) {
+	logging.FromContext(ctx, l.log).Errorf("panic in GraphQL resolver: %v\n%s", value, debug.Stack())
+}
+
+func NewExecutor(log *logrus.Entry, registry *service.Registry, validator Validator, opts Options) (Executor, error) {
+	schemaOpts := []graphqlgo.SchemaOpt{graphqlgo.Logger(panicLogger{log: log})}
+	if opts.MaxDepth > 0 {
+		schemaOpts = append(schemaOpts, graphqlgo.MaxDepth(opts.MaxDepth))
+	}
+	if opts.MaxParallelism > 0 {
+		schemaOpts = append(schemaOpts, graphqlgo.MaxParallelism(opts.MaxParallelism))
+	}
+	if !opts.Introspection {
+		schemaOpts = append(schemaOpts, graphqlgo.DisableIntrospection())
+	}
+	if opts.Tracing {
+		schemaOpts = append(schemaOpts, graphqlgo.Tracer(graphqlotel.DefaultTracer()))
+	}
+
+	root := &resolver{log: log, registry: registry, validator: validator}
+	schema, err := graphqlgo.ParseSchema(schemaSource, root, schemaOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("parse GraphQL schema: %w", err)
+	}
+	return &executorImpl{schema: schema, registry: registry, maxCost: opts.MaxCost}, nil
+}
@@ -0,0 +1,222 @@
+package graphql
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/model/core"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+)
+
+type userResolver struct {
+	user *core.User
+}
+
+func (r *userResolver) Nickname() string { return r.user.Nickname }
+func (r *userResolver) Fullname() string { return r.user.Fullname }
+func (r *userResolver) About() string    { return r.user.About }
+func (r *userResolver) Email() string    { return r.user.Email }
+
+type forumResolver struct {
+	root  *resolver
+	forum *core.Forum
+}
+
+func (r *forumResolver) Slug() string       { return r.forum.Slug }
+func (r *forumResolver) Title() string      { return r.forum.Title }
+func (r *forumResolver) PostCount() int32   { return int32(r.forum.Posts) }
+func (r *forumResolver) ThreadCount() int32 { return int32(r.forum.Threads) }
+
+func (r *forumResolver) User(ctx context.Context) (*userResolver, error) {
+	return r.root.loadUser(ctx, r.forum.User)
+}
+
+func (r *forumResolver) Threads(ctx context.Context, args struct {
+	Limit int32
+	Since *graphqlgo.Time
+	Desc  bool
+}) ([]*threadResolver, error) {
+	if err := r.root.charge(ctx, args.Limit); err != nil {
+		return nil, err
+	}
+	request := &dto.GetForumThreadsRequest{Slug: r.forum.Slug, Limit: int64(args.Limit), Desc: args.Desc}
+	if args.Since != nil {
+		request.Since = args.Since.Format(time.RFC3339Nano)
+	}
+	if err := r.root.validate(request); err != nil {
+		return nil, r.root.fail(ctx, err)
+	}
+	threads, err := r.root.registry.ForumService.GetForumThreads(ctx, request)
+	if err != nil {
+		return nil, r.root.fail(ctx, err)
+	}
+	return r.root.threadResolvers(ctx, threads), nil
+}
+
+func (r *forumResolver) Users(ctx context.Context, args struct {
+	Limit int32
+	Since *string
+	Desc  bool
+}) ([]*userResolver, error) {
+	if err := r.root.charge(ctx, args.Limit); err != nil {
+		return nil, err
+	}
+	request := &dto.GetForumUsersRequest{Slug: r.forum.Slug, Limit: int64(args.Limit), Desc: args.Desc}
+	if args.Since != nil {
+		request.Since = *args.Since
+	}
+	if err := r.root.validate(request); err != nil {
+		return nil, r.root.fail(ctx, err)
+	}
+	users, err := r.root.registry.ForumService.GetForumUsers(ctx, request)
+	if err != nil {
+		return nil, r.root.fail(ctx, err)
+	}
+	return userResolvers(users), nil
+}
+
+func (r *forumResolver) Moderators(ctx context.Context) ([]*userResolver, error) {
+	users, err := r.root.registry.ForumService.GetModerators(ctx, &dto.GetModeratorsRequest{Slug: r.forum.Slug})
+	if err != nil {
+		return nil, r.root.fail(ctx, err)
+	}
+	return userResolvers(users), nil
+}
+
+type threadResolver struct {
+	root   *resolver
+	thread *core.Thread
+}
+
+func (r *threadResolver) ID() graphqlgo.ID        { return id(r.thread.ID) }
+func (r *threadResolver) Title() string           { return r.thread.Title }
+func (r *threadResolver) Message() string         { return r.thread.Message }
+func (r *threadResolver) Votes() int32            { return int32(r.thread.Votes) }
+func (r *threadResolver) Created() graphqlgo.Time { return graphqlgo.Time{Time: r.thread.Created} }
+func (r *threadResolver) Locked() bool            { return r.thread.Locked }
+
+func (r *threadResolver) Slug() *string {
+	if r.thread.Slug == "" {
+		return nil
+	}
+	return &r.thread.Slug
+}
+
+func (r *threadResolver) Author(ctx context.Context) (*userResolver, error) {
+	return r.root.loadUser(ctx, r.thread.Author)
+}
+
+func (r *threadResolver) Forum(ctx context.Context) (*forumResolver, error) {
+	return r.root.loadForum(ctx, r.thread.Forum)
+}
+
+func (r *threadResolver) Posts(ctx context.Context, args struct {
+	Limit int32
+	Since *graphqlgo.ID
+	Sort  string
+	Desc  bool
+}) ([]*postResolver, error) {
+	if err := r.root.charge(ctx, args.Limit); err != nil {
+		return nil, err
+	}
+	request := &dto.GetPostsRequest{
+		SlugOrID: strconv.FormatInt(r.thread.ID, 10),
+		Sort:     strings.ToLower(args.Sort),
+		Limit:    int64(args.Limit),
+		Desc:     args.Desc,
+	}
+	since := int64(-1)
+	if args.Since != nil {
+		var err error
+		if since, err = parseID("since", *args.Since); err != nil {
+			return nil, r.root.fail(ctx, err)
+		}
+		request.Since = &since
+	}
+	if err := r.root.validate(request); err != nil {
+		return nil, r.root.fail(ctx, err)
+	}
+	posts, err := r.root.registry.PostsService.GetPosts(ctx, request.SlugOrID, request.Sort, since, request.Desc, request.Limit)
+	if err != nil {
+		return nil, r.root.fail(ctx, err)
+	}
+	return r.root.postResolvers(ctx, posts), nil
+}
+
+type postResolver struct {
+	root *resolver
+	post *core.Post
+}
+
+func (r *postResolver) ID() graphqlgo.ID        { return id(r.post.ID) }
+func (r *postResolver) Message() string         { return r.post.Message }
+func (r *postResolver) IsEdited() bool          { return r.post.IsEdited }
+func (r *postResolver) Created() graphqlgo.Time { return graphqlgo.Time{Time: r.post.Created} }
+
+func (r *postResolver) Parent() *graphqlgo.ID {
+	if r.post.Parent == 0 {
+		return nil
+	}
+	parent := id(r.post.Parent)
+	return &parent
+}
+
+func (r *postResolver) Author(ctx context.Context) (*userResolver, error) {
+	return r.root.loadUser(ctx, r.post.Author)
+}
+
+func (r *postResolver) Forum(ctx context.Context) (*forumResolver, error) {
+	return r.root.loadForum(ctx, r.post.Forum)
+}
+
+func (r *postResolver) Thread(ctx context.Context) (*threadResolver, error) {
+	thread, found, err := loadersFrom(ctx).threads.Load(ctx, r.post.Thread)
+	if err != nil {
+		return nil, r.root.fail(ctx, err)
+	}
+	if !found {
+		return nil, r.root.fail(ctx, domain.NotFound("thread", strconv.FormatInt(r.post.Thread, 10), "Can't find thread by id: %d", r.post.Thread))
+	}
+	return &threadResolver{root: r.root, thread: thread}, nil
+}
+
+type voteResolver struct {
+	root     *resolver
+	nickname string
+	voice    int64
+	thread   *core.Thread
+}
+
+func (r *voteResolver) Voice() int32 { return int32(r.voice) }
+
+func (r *voteResolver) User(ctx context.Context) (*userResolver, error) {
+	return r.root.loadUser(ctx, r.nickname)
+}
+
+func (r *voteResolver) Thread() *threadResolver {
+	return &threadResolver{root: r.root, thread: r.thread}
+}
+
+func id(value int64) graphqlgo.ID {
+	return graphqlgo.ID(strconv.FormatInt(value, 10))
+}
+
+func parseID(field string, value graphqlgo.ID) (int64, error) {
+	parsed, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, domain.InvalidFields(domain.FieldError{Field: field, Message: "must be an integer"})
+	}
+	return parsed, nil
+}
+
+func userResolvers(users []*core.User) []*userResolver {
+	resolvers := make([]*userResolver, 0, len(users))
+	for _, user := range users {
+		resolvers = append(resolvers, &userResolver{user: user})
+	}
+	return resolvers
+}
@@ -0,0 +1,26 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type contextKey struct{}
+
+// WithLogger stores a request scoped logger in ctx.
+func WithLogger(ctx context.Context, log *logrus.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, log)
+}
+
+// FromContext returns the logger stored by WithLogger, or fallback when the
+// context carries none. A nil fallback means the standard logrus logger.
+func FromContext(ctx context.Context, fallback *logrus.Entry) *logrus.Entry {
+	if log, ok := ctx.Value(contextKey{}).(*logrus.Entry); ok {
+		return log
+	}
+	if fallback != nil {
+		return fallback
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rinatkh/db_forum/internal/db"
+	"github.com/sirupsen/logrus"
+)
+
+// RepositoryHook logs repository calls with the request scoped logger: failures
+// at error level, everything else at debug level.
+type RepositoryHook struct {
+	log *logrus.Entry
+}
+
+func (h *RepositoryHook) BeforeQuery(ctx context.Context, _ *db.Call) context.Context {
+	return ctx
+}
+
+func (h *RepositoryHook) AfterQuery(ctx context.Context, call *db.Call) {
+	failed := call.Err != nil && !errors.Is(call.Err, pgx.ErrNoRows)
+	if !failed && !h.log.Logger.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+
+	log := FromContext(ctx, h.log).WithFields(logrus.Fields{
+		"repository": call.Repository,
+		"method":     call.Method,
+		"rows":       call.Rows,
+		"duration":   time.Since(call.Start).String(),
+	})
+	if failed {
+		log.Errorf("repository call failed: %s", call.Err)
+		return
+	}
+	log.Debug("repository call")
+}
+
+func NewRepositoryHook(log *logrus.Entry) *RepositoryHook {
+	return &RepositoryHook{log: log}
+}
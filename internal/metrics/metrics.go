@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rinatkh/db_forum/internal/db"
+)
+
+const namespace = "db_forum"
+
+// domainCounters maps repository calls onto business counters; the counter is
+// increased by the number of rows the call reports.
+var domainCounters = map[string]string{
+	"UserRepository.CreateUser":     "users",
+	"ForumRepository.CreateForum":   "forums",
+	"ThreadRepository.CreateThread": "threads",
+	"PostsRepository.CreatePosts":   "posts",
+	"VotesRepository.CreateVote":    "votes",
+	"VotesRepository.EditVote":      "votes",
+}
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	queryDuration *prometheus.HistogramVec
+	queryErrors   *prometheus.CounterVec
+	created       *prometheus.CounterVec
+}
+
+// Middleware records request count and latency per echo route and status.
+func (m *Metrics) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			start := time.Now()
+			if err := next(ctx); err != nil {
+				ctx.Error(err)
+			}
+			status := ctx.Response().Status
+
+			route := ctx.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			labels := prometheus.Labels{
+				"method": ctx.Request().Method,
+				"route":  route,
+				"status": strconv.Itoa(status),
+			}
+			m.httpRequests.With(labels).Inc()
+			m.httpDuration.With(labels).Observe(time.Since(start).Seconds())
+			return nil
+		}
+	}
+}
+
+// Handler serves the collected metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) BeforeQuery(ctx context.Context, _ *db.Call) context.Context {
+	return ctx
+}
+
+func (m *Metrics) AfterQuery(_ context.Context, call *db.Call) {
+	m.queryDuration.WithLabelValues(call.Repository, call.Method).Observe(time.Since(call.Start).Seconds())
+	if call.Err != nil && !errors.Is(call.Err, pgx.ErrNoRows) {
+		m.queryErrors.WithLabelValues(call.Repository, call.Method).Inc()
+		return
+	}
+	if entity, ok := domainCounters[call.Repository+"."+call.Method]; ok && call.Rows > 0 {
+		m.created.WithLabelValues(entity).Add(float64(call.Rows))
+	}
+}
+
+func New(dbConn *pgxpool.Pool) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of handled HTTP requests.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 16),
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_duration_seconds",
+			Help:      "Repository method latency.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 16),
+		}, []string{"repository", "method"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_errors_total",
+			Help:      "Repository calls that failed with anything but a missing row.",
+		}, []string{"repository", "method"}),
+		created: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "entities_created_total",
+			Help:      "Created users, forums, threads and posts, and cast votes.",
+		}, []string{"entity"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.queryDuration,
+		m.queryErrors,
+		m.created,
+		newPoolCollector(dbConn),
+	)
+	return m
+}
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS Votes, ForumUsers, Posts, Threads, Forums, Users CASCADE;
+
+DROP FUNCTION IF EXISTS insert_vote();
+DROP FUNCTION IF EXISTS update_vote();
+DROP FUNCTION IF EXISTS count_forum_threads();
+DROP FUNCTION IF EXISTS count_forum_posts();
+DROP FUNCTION IF EXISTS update_post_path();
+DROP FUNCTION IF EXISTS update_users_from_forum();
@@ -1,7 +1,5 @@
 CREATE EXTENSION IF NOT EXISTS CITEXT;
 
-DROP TABLE IF EXISTS Users, Forums, Threads, Posts, Posts, ForumUsers CASCADE;
-
 CREATE UNLOGGED TABLE IF NOT EXISTS Users (
     id SERIAL,
     nickname CITEXT COLLATE "C" NOT NULL PRIMARY KEY,
@@ -19,7 +17,7 @@ CREATE UNLOGGED TABLE IF NOT EXISTS Forums (
     threads INT NOT NULL DEFAULT 0
 );
 
-CREATE UNLOGGED TABLE Threads (
+CREATE UNLOGGED TABLE IF NOT EXISTS Threads (
     id SERIAL NOT NULL PRIMARY KEY,
     slug CITEXT,
     title TEXT NOT NULL,
@@ -119,12 +117,18 @@ DECLARE
 $$ LANGUAGE plpgsql;
 
 
+-- Databases created by the old db.sql already have the triggers.
+DROP TRIGGER IF EXISTS insert_votes ON Votes;
 CREATE TRIGGER insert_votes AFTER INSERT ON Votes FOR EACH ROW EXECUTE PROCEDURE insert_vote();
+DROP TRIGGER IF EXISTS update_votes ON Votes;
 CREATE TRIGGER update_votes AFTER UPDATE ON Votes FOR EACH ROW EXECUTE PROCEDURE update_vote();
+DROP TRIGGER IF EXISTS count_threads ON Threads;
 CREATE TRIGGER count_threads AFTER INSERT ON Threads FOR EACH ROW EXECUTE PROCEDURE count_forum_threads();
+DROP TRIGGER IF EXISTS count_posts ON Posts;
 CREATE TRIGGER count_posts AFTER INSERT ON Posts FOR EACH ROW EXECUTE PROCEDURE count_forum_posts();
+DROP TRIGGER IF EXISTS update_post_path ON Posts;
 CREATE TRIGGER update_post_path BEFORE INSERT ON Posts FOR EACH ROW EXECUTE PROCEDURE update_post_path();
+DROP TRIGGER IF EXISTS update_users_on_post ON Posts;
 CREATE TRIGGER update_users_on_post AFTER INSERT ON Posts FOR EACH ROW EXECUTE PROCEDURE update_users_from_forum();
+DROP TRIGGER IF EXISTS update_users_on_thread ON Threads;
 CREATE TRIGGER update_users_on_thread AFTER INSERT ON Threads FOR EACH ROW EXECUTE PROCEDURE update_users_from_forum();
-
-VACUUM ANALYZE;
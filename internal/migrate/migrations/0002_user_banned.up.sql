@@ -0,0 +1 @@
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS banned BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS touch_post ON Posts;
+DROP TRIGGER IF EXISTS touch_thread ON Threads;
+DROP FUNCTION IF EXISTS touch_modified();
+ALTER TABLE Posts DROP COLUMN IF EXISTS modified;
+ALTER TABLE Threads DROP COLUMN IF EXISTS modified;
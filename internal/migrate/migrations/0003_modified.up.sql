@@ -0,0 +1,16 @@
+ALTER TABLE Threads ADD COLUMN IF NOT EXISTS modified TIMESTAMP WITH TIME ZONE;
+ALTER TABLE Posts ADD COLUMN IF NOT EXISTS modified TIMESTAMP WITH TIME ZONE;
+UPDATE Threads SET modified = COALESCE(created, now()) WHERE modified IS NULL;
+UPDATE Posts SET modified = COALESCE(created, now()) WHERE modified IS NULL;
+ALTER TABLE Threads ALTER COLUMN modified SET DEFAULT now(), ALTER COLUMN modified SET NOT NULL;
+ALTER TABLE Posts ALTER COLUMN modified SET DEFAULT now(), ALTER COLUMN modified SET NOT NULL;
+
+CREATE OR REPLACE FUNCTION touch_modified() RETURNS TRIGGER AS $$
+    BEGIN
+        NEW.modified = now();
+        RETURN NEW;
+    END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER touch_thread BEFORE UPDATE ON Threads FOR EACH ROW EXECUTE PROCEDURE touch_modified();
+CREATE TRIGGER touch_post BEFORE UPDATE ON Posts FOR EACH ROW EXECUTE PROCEDURE touch_modified();
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS ApiTokens;
+DROP TABLE IF EXISTS Credentials;
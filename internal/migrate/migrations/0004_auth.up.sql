@@ -0,0 +1,14 @@
+CREATE UNLOGGED TABLE IF NOT EXISTS Credentials (
+    nickname      CITEXT COLLATE "C" PRIMARY KEY REFERENCES Users(nickname) ON DELETE CASCADE,
+    password_hash TEXT NOT NULL,
+    updated       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE UNLOGGED TABLE IF NOT EXISTS ApiTokens (
+    token_hash BYTEA PRIMARY KEY,
+    nickname   CITEXT COLLATE "C" NOT NULL REFERENCES Users(nickname) ON DELETE CASCADE,
+    name       TEXT NOT NULL DEFAULT '',
+    created    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS api_tokens_nickname ON ApiTokens(nickname);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS ForumModerators;
+ALTER TABLE Threads DROP COLUMN IF EXISTS locked;
+ALTER TABLE Users DROP COLUMN IF EXISTS admin;
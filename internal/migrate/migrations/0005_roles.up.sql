@@ -0,0 +1,10 @@
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS admin BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE Threads ADD COLUMN IF NOT EXISTS locked BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE UNLOGGED TABLE IF NOT EXISTS ForumModerators (
+    forum      CITEXT COLLATE "C" NOT NULL REFERENCES Forums(slug) ON DELETE CASCADE,
+    nickname   CITEXT COLLATE "C" NOT NULL REFERENCES Users(nickname) ON DELETE CASCADE,
+    granted_by CITEXT COLLATE "C",
+    created    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    PRIMARY KEY (forum, nickname)
+);
@@ -0,0 +1,12 @@
+CREATE UNLOGGED TABLE IF NOT EXISTS IdempotencyKeys (
+    scope        CITEXT COLLATE "C" NOT NULL,
+    key          TEXT NOT NULL,
+    request_hash BYTEA NOT NULL,
+    status       INT NOT NULL DEFAULT 0,
+    content_type TEXT NOT NULL DEFAULT '',
+    body         BYTEA,
+    expires      TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires ON IdempotencyKeys(expires);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS notify_thread_votes ON Threads;
+DROP TRIGGER IF EXISTS notify_post_edited ON Posts;
+DROP TRIGGER IF EXISTS notify_post_created ON Posts;
+DROP FUNCTION IF EXISTS notify_thread_votes();
+DROP FUNCTION IF EXISTS notify_post();
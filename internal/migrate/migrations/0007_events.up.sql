@@ -0,0 +1,33 @@
+-- Changes are announced only by sessions with forum.notify set to on, so
+-- that deployments without listeners don't pay for the notifications.
+CREATE OR REPLACE FUNCTION notify_post() RETURNS TRIGGER AS $$
+    BEGIN
+        IF current_setting('forum.notify', true) IS DISTINCT FROM 'on' THEN
+            RETURN NULL;
+        END IF;
+        PERFORM pg_notify('forum_events', json_build_object(
+            'type', CASE TG_OP WHEN 'INSERT' THEN 'post_created' ELSE 'post_edited' END,
+            'thread', NEW.thread,
+            'id', NEW.id)::text);
+        RETURN NULL;
+    END
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION notify_thread_votes() RETURNS TRIGGER AS $$
+    BEGIN
+        IF current_setting('forum.notify', true) IS DISTINCT FROM 'on' THEN
+            RETURN NULL;
+        END IF;
+        PERFORM pg_notify('forum_events', json_build_object(
+            'type', 'votes_changed',
+            'thread', NEW.id,
+            'votes', NEW.votes)::text);
+        RETURN NULL;
+    END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER notify_post_created AFTER INSERT ON Posts FOR EACH ROW EXECUTE PROCEDURE notify_post();
+CREATE TRIGGER notify_post_edited AFTER UPDATE OF message ON Posts FOR EACH ROW
+    WHEN (OLD.message IS DISTINCT FROM NEW.message) EXECUTE PROCEDURE notify_post();
+CREATE TRIGGER notify_thread_votes AFTER UPDATE OF votes ON Threads FOR EACH ROW
+    WHEN (OLD.votes IS DISTINCT FROM NEW.votes) EXECUTE PROCEDURE notify_thread_votes();
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS touch_thread ON Threads;
+CREATE TRIGGER touch_thread BEFORE UPDATE ON Threads FOR EACH ROW EXECUTE PROCEDURE touch_modified();
+DROP TRIGGER IF EXISTS count_thread_posts ON Posts;
+DROP FUNCTION IF EXISTS count_thread_posts();
+ALTER TABLE Threads DROP COLUMN IF EXISTS last_post;
+ALTER TABLE Threads DROP COLUMN IF EXISTS posts;
@@ -0,0 +1,25 @@
+ALTER TABLE Threads ADD COLUMN IF NOT EXISTS posts INT NOT NULL DEFAULT 0;
+ALTER TABLE Threads ADD COLUMN IF NOT EXISTS last_post TIMESTAMP WITH TIME ZONE;
+
+-- The counters are not part of the thread as v1 shows it, so they leave its
+-- modification time alone.
+DROP TRIGGER IF EXISTS touch_thread ON Threads;
+CREATE TRIGGER touch_thread BEFORE UPDATE ON Threads FOR EACH ROW
+    WHEN (OLD.posts IS NOT DISTINCT FROM NEW.posts) EXECUTE PROCEDURE touch_modified();
+
+UPDATE Threads SET posts = p.posts, last_post = p.last_post
+    FROM (SELECT thread, count(*) AS posts, max(created) AS last_post FROM Posts GROUP BY thread) p
+    WHERE Threads.id = p.thread;
+
+-- Counted once per statement, as posts are inserted in batches.
+CREATE OR REPLACE FUNCTION count_thread_posts() RETURNS TRIGGER AS $$
+    BEGIN
+        UPDATE Threads SET posts = Threads.posts + p.posts, last_post = GREATEST(Threads.last_post, p.last_post)
+            FROM (SELECT thread, count(*) AS posts, max(created) AS last_post FROM inserted GROUP BY thread) p
+            WHERE Threads.id = p.thread;
+        RETURN NULL;
+    END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER count_thread_posts AFTER INSERT ON Posts REFERENCING NEW TABLE AS inserted
+    FOR EACH STATEMENT EXECUTE PROCEDURE count_thread_posts();
@@ -19,6 +19,8 @@ type Post struct {
 	Author   string    `json:"author"`
 	Thread   int64     `json:"thread"`
 	Created  time.Time `json:"created"`
+	// Modified is the time of the last change, loaded for conditional GETs.
+	Modified time.Time `json:"-"`
 }
 
 type ServiceInfo struct {
@@ -37,6 +39,26 @@ type Thread struct {
 	Author  string    `json:"author"`
 	Slug    string    `json:"slug"`
 	Created time.Time `json:"created"`
+	// Locked threads only accept posts from moderators. v1 answers keep to
+	// swagger.yaml and leave it out; v2 and the other APIs expose it.
+	Locked bool `json:"-"`
+	// Modified is the time of the last change, loaded for conditional GETs.
+	Modified time.Time `json:"-"`
+}
+
+// ThreadActivity is how much and how recently a thread was posted to.
+type ThreadActivity struct {
+	ID    int64
+	Posts int64
+	// LastActivity is the latest of the last post and the last change of
+	// the thread itself.
+	LastActivity time.Time
+}
+
+// PostDepth is the nesting level of a post, 0 for posts answering the thread.
+type PostDepth struct {
+	ID    int64
+	Depth int64
 }
 
 type User struct {
@@ -44,10 +66,87 @@ type User struct {
 	About    string `json:"about"`
 	Nickname string `json:"nickname"`
 	Email    string `json:"email"`
+	Banned   bool   `json:"-"`
 }
 
 type Vote struct {
-	Nickname string
-	ThreadID int64
-	Voice    int64
+	Nickname string `json:"nickname"`
+	ThreadID int64  `json:"thread"`
+	Voice    int64  `json:"voice"`
+}
+
+// Credential is the password hash stored for a user.
+type Credential struct {
+	Nickname     string    `json:"nickname"`
+	PasswordHash string    `json:"password_hash"`
+	Updated      time.Time `json:"updated"`
+}
+
+// APIToken is a long-lived token of a user; only its hash is stored.
+type APIToken struct {
+	Nickname  string    `json:"nickname"`
+	TokenHash []byte    `json:"token_hash"`
+	Name      string    `json:"name,omitempty"`
+	Created   time.Time `json:"created"`
+}
+
+// Moderator is a user appointed to moderate a forum.
+type Moderator struct {
+	Forum     string    `json:"forum"`
+	Nickname  string    `json:"nickname"`
+	GrantedBy string    `json:"granted_by,omitempty"`
+	Created   time.Time `json:"created"`
+}
+
+// DumpRecord is a single line of a database export; exactly one of the
+// entity fields is set, according to Type. Flags the API encoding of an
+// entity leaves out are carried beside it: Banned and Admin for User, Locked
+// for Thread and Modified for Thread and Post.
+type DumpRecord struct {
+	Type   string  `json:"type"`
+	User   *User   `json:"user,omitempty"`
+	Banned bool    `json:"banned,omitempty"`
+	Admin  bool    `json:"admin,omitempty"`
+	Forum  *Forum  `json:"forum,omitempty"`
+	Thread *Thread `json:"thread,omitempty"`
+	Locked bool    `json:"locked,omitempty"`
+	Post   *Post   `json:"post,omitempty"`
+	// Modified is absent from dumps of older releases.
+	Modified *time.Time `json:"modified,omitempty"`
+	Vote     *Vote      `json:"vote,omitempty"`
+
+	Credential *Credential `json:"credential,omitempty"`
+	APIToken   *APIToken   `json:"api_token,omitempty"`
+	Moderator  *Moderator  `json:"moderator,omitempty"`
+}
+
+const (
+	DumpUser   = "user"
+	DumpForum  = "forum"
+	DumpThread = "thread"
+	DumpPost   = "post"
+	DumpVote   = "vote"
+
+	DumpCredential = "credential"
+	DumpAPIToken   = "api_token"
+	DumpModerator  = "moderator"
+)
+
+// Membership describes the standing of a user on the site and in one forum,
+// as needed for authorization decisions.
+type Membership struct {
+	Nickname  string
+	Admin     bool
+	Banned    bool
+	Owner     bool
+	Moderator bool
+}
+
+// IdempotentResponse is the response stored under an Idempotency-Key for
+// replay. Status is zero while the first request is still being handled.
+type IdempotentResponse struct {
+	RequestHash []byte
+	Status      int
+	ContentType string
+	Body        []byte
 }
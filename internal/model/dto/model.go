@@ -1,37 +1,83 @@
 package dto
 
 import (
+	"encoding/json"
 	"github.com/rinatkh/db_forum/internal/model/core"
 	"time"
 )
 
 type CreateForumRequest struct {
-	Title string `json:"title"`
-	User  string `json:"user"`
-	Slug  string `json:"slug"`
+	Title string `json:"title" validate:"required"`
+	User  string `json:"user" validate:"required,nickname"`
+	Slug  string `json:"slug" validate:"required,slug"`
 }
 
 type GetForumRequest struct {
-	Slug string `path:"slug"`
+	Slug string `param:"slug" validate:"required,slug"`
+}
+
+type DeleteForumRequest struct {
+	Slug string `param:"slug" validate:"required,slug"`
+}
+
+type GetModeratorsRequest struct {
+	Slug string `param:"slug" validate:"required,slug"`
+}
+
+// ModeratorRequest names the user either in the body, when granting, or in
+// the path, when revoking.
+type ModeratorRequest struct {
+	Slug     string `param:"slug" json:"-" validate:"required,slug"`
+	Nickname string `param:"nickname" json:"nickname" validate:"required,nickname"`
 }
 
 type GetForumThreadsRequest struct {
-	Slug  string `path:"slug"`
-	Limit int64  `query:"limit"`
-	Since string `query:"since"`
-	Desc  bool   `query:"desc"`
+	Slug   string `param:"slug" validate:"required,slug"`
+	Limit  int64  `query:"limit" validate:"omitempty,min=1,max=10000"`
+	Since  string `query:"since" validate:"omitempty,rfc3339"`
+	Desc   bool   `query:"desc"`
+	Cursor string `query:"cursor"`
+	Fields string `query:"fields" validate:"omitempty,fields=thread"`
+	Embed  string `query:"embed" validate:"omitempty,embed"`
 }
 
 type GetForumUsersRequest struct {
-	Slug  string `path:"slug"`
-	Limit int64  `query:"limit"`
-	Since string `query:"since"`
-	Desc  bool   `query:"desc"`
+	Slug   string `param:"slug" validate:"required,slug"`
+	Limit  int64  `query:"limit" validate:"omitempty,min=1,max=10000"`
+	Since  string `query:"since" validate:"omitempty,nickname"`
+	Desc   bool   `query:"desc"`
+	Cursor string `query:"cursor"`
+	Fields string `query:"fields" validate:"omitempty,fields=user"`
 }
 type Post struct {
-	Parent  int64  `json:"parent"`
-	Author  string `json:"author"`
-	Message string `json:"message"`
+	Parent  int64  `json:"parent" validate:"min=0"`
+	Author  string `json:"author" validate:"required,nickname"`
+	Message string `json:"message" validate:"required"`
+}
+
+type GetPostsRequest struct {
+	SlugOrID string `param:"slug_or_id" validate:"required"`
+	Sort     string `query:"sort" validate:"omitempty,oneof=flat tree parent_tree"`
+	Since    *int64 `query:"since" validate:"omitempty,min=0"`
+	Limit    int64  `query:"limit" validate:"omitempty,min=1,max=10000"`
+	Desc     bool   `query:"desc"`
+	Cursor   string `query:"cursor"`
+	Fields   string `query:"fields" validate:"omitempty,fields=post"`
+	Embed    string `query:"embed" validate:"omitempty,embed"`
+}
+
+// Page is a listing answered in cursor mode. The cursors are opaque and only
+// valid for the listing that produced them.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// Embedded holds the objects a listed item refers to, inlined on request.
+type Embedded struct {
+	Author *core.User  `json:"author,omitempty"`
+	Forum  *core.Forum `json:"forum,omitempty"`
 }
 
 type PostDetails struct {
@@ -42,50 +88,65 @@ type PostDetails struct {
 }
 
 type GetPostDetailsRequest struct {
-	Related string `query:"related"`
-	ID      int64  `path:"id"`
+	Related string `query:"related" validate:"omitempty,related"`
+	ID      int64  `param:"id" validate:"min=1"`
 }
 
 type EditPostRequest struct {
 	Message string `json:"message"`
-	ID      int64  `path:"id"`
+	ID      int64  `param:"id" validate:"min=1"`
 }
 
 type ErrorResponse struct {
-	Message string `json:"message"`
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors,omitempty"`
 }
 
-type Response struct {
-	Data interface{}
-	Code int
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
+
 type CreateThreadRequest struct {
-	Author  string    `json:"author"`
-	Forum   string    `path:"slug"`
-	Slug    string    `json:"slug"`
-	Title   string    `json:"title"`
-	Message string    `json:"message"`
+	Author  string    `json:"author" validate:"required,nickname"`
+	Forum   string    `param:"slug" validate:"required,slug"`
+	Slug    string    `json:"slug" validate:"omitempty,slug"`
+	Title   string    `json:"title" validate:"required"`
+	Message string    `json:"message" validate:"required"`
 	Created time.Time `json:"created,omitempty"`
 }
 
+type GetThreadRequest struct {
+	SlugOrID string `param:"slug_or_id" validate:"required"`
+	Fields   string `query:"fields" validate:"omitempty,fields=thread"`
+}
+
 type EditVoteRequest struct {
-	Voice    int64  `json:"voice"`
-	Nickname string `json:"nickname"`
+	Voice    int64  `json:"voice" validate:"oneof=-1 1"`
+	Nickname string `json:"nickname" validate:"required,nickname"`
 }
 
 type EditThreadRequest struct {
 	Message string `json:"message"`
 	Title   string `json:"title"`
 }
+
+type LockThreadRequest struct {
+	Locked bool `json:"locked"`
+}
+
 type CreateUserRequest struct {
-	Nickname string `path:"nickname"`
-	Fullname string `json:"fullname"`
+	Nickname string `param:"nickname" json:"-" validate:"required,nickname"`
+	Fullname string `json:"fullname" validate:"required"`
 	About    string `json:"about"`
-	Email    string `json:"email"`
+	Email    string `json:"email" validate:"required,email"`
+	// Password optionally sets the credentials used by POST /api/auth/login.
+	Password string `json:"password,omitempty" validate:"omitempty,min=8,max=72"`
 }
 
 type GetUserProfileRequest struct {
-	Nickname string `path:"nickname"`
+	Nickname string `param:"nickname" validate:"required,nickname"`
+	Fields   string `query:"fields" validate:"omitempty,fields=user"`
 }
 
 type GetUserProfileResponse struct {
@@ -96,8 +157,63 @@ type GetUserProfileResponse struct {
 }
 
 type EditUserProfileRequest struct {
-	Nickname string `path:"nickname"`
+	Nickname string `param:"nickname" json:"-" validate:"required,nickname"`
 	About    string `json:"about"`
-	Email    string `json:"email"`
+	Email    string `json:"email" validate:"omitempty,email"`
 	Fullname string `json:"fullname"`
 }
+
+type BanUserRequest struct {
+	Nickname string `param:"nickname" json:"-" validate:"required,nickname"`
+	Banned   bool   `json:"banned"`
+}
+
+type SetUserAdminRequest struct {
+	Nickname string `param:"nickname" json:"-" validate:"required,nickname"`
+	Admin    bool   `json:"admin"`
+}
+
+type LoginRequest struct {
+	Nickname string `json:"nickname" validate:"required,nickname"`
+	Password string `json:"password" validate:"required"`
+}
+
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type SetPasswordRequest struct {
+	Nickname string `param:"nickname" json:"-" validate:"required,nickname"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type GraphQLRequest struct {
+	Query         string                 `json:"query" validate:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type BatchRequest struct {
+	// Atomic runs the requests in one transaction that is rolled back when
+	// any of them fails.
+	Atomic   bool         `json:"atomic"`
+	Requests []*BatchItem `json:"requests" validate:"required,min=1,dive,required"`
+}
+
+type BatchItem struct {
+	Method string          `json:"method" validate:"required,oneof=GET POST DELETE"`
+	Path   string          `json:"path" validate:"required,startswith=/api/"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+type BatchResponse struct {
+	// Committed is set for atomic batches.
+	Committed *bool                `json:"committed,omitempty"`
+	Responses []*BatchItemResponse `json:"responses"`
+}
+
+type BatchItemResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
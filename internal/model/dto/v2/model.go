@@ -0,0 +1,170 @@
+// Package dtov2 holds the resources of the /api/v2 routes. Unlike the v1
+// DTOs they are not bound by swagger.yaml: names are snake_case, timestamps
+// always carry their offset, listings come in an envelope with pagination
+// metadata and errors have a machine-readable code.
+package dtov2
+
+import (
+	"time"
+
+	"github.com/rinatkh/db_forum/internal/model/core"
+)
+
+// TimeFormat is RFC 3339 with millisecond precision and a numeric offset.
+const TimeFormat = "2006-01-02T15:04:05.000-07:00"
+
+// Time is a timestamp encoded in TimeFormat, in UTC. It decodes from any
+// RFC 3339 timestamp.
+type Time struct {
+	time.Time
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + t.UTC().Format(TimeFormat) + `"`), nil
+}
+
+type User struct {
+	Nickname string `json:"nickname"`
+	Fullname string `json:"fullname"`
+	About    string `json:"about"`
+	Email    string `json:"email"`
+}
+
+type Forum struct {
+	Slug    string `json:"slug"`
+	Title   string `json:"title"`
+	User    string `json:"user"`
+	Threads int64  `json:"threads"`
+	Posts   int64  `json:"posts"`
+}
+
+type Thread struct {
+	ID      int64  `json:"id"`
+	Slug    string `json:"slug,omitempty"`
+	Forum   string `json:"forum"`
+	Author  string `json:"author"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	Votes   int64  `json:"votes"`
+	Locked  bool   `json:"locked"`
+	Created Time   `json:"created"`
+	Posts   int64  `json:"posts"`
+	// LastActivity is the time of the last post or change of the thread.
+	LastActivity Time `json:"last_activity"`
+}
+
+type Post struct {
+	ID       int64  `json:"id"`
+	Parent   int64  `json:"parent"`
+	Depth    int64  `json:"depth"`
+	Forum    string `json:"forum"`
+	Thread   int64  `json:"thread"`
+	Author   string `json:"author"`
+	Message  string `json:"message"`
+	IsEdited bool   `json:"is_edited"`
+	Created  Time   `json:"created"`
+}
+
+type PostDetails struct {
+	Post   *Post   `json:"post"`
+	Author *User   `json:"author,omitempty"`
+	Thread *Thread `json:"thread,omitempty"`
+	Forum  *Forum  `json:"forum,omitempty"`
+}
+
+// List is one page of a listing.
+type List[T any] struct {
+	Data       []T        `json:"data"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// Pagination describes the page a List holds. The cursors are opaque, only
+// valid for the listing that produced them and absent at either end.
+type Pagination struct {
+	Limit      int64  `json:"limit"`
+	Count      int    `json:"count"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// GetThreadPostsRequest lists the posts of a thread page by page.
+type GetThreadPostsRequest struct {
+	SlugOrID string `param:"slug_or_id" validate:"required"`
+	Sort     string `query:"sort" validate:"omitempty,oneof=flat tree parent_tree"`
+	Limit    int64  `query:"limit" validate:"omitempty,min=1,max=10000"`
+	Desc     bool   `query:"desc"`
+	Cursor   string `query:"cursor"`
+}
+
+// GetForumListRequest lists the threads or the users of a forum page by page.
+type GetForumListRequest struct {
+	Slug   string `param:"slug" validate:"required,slug"`
+	Limit  int64  `query:"limit" validate:"omitempty,min=1,max=10000"`
+	Desc   bool   `query:"desc"`
+	Cursor string `query:"cursor"`
+}
+
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// Error describes a failed request. Code is stable for clients to act on,
+// e.g. not_found, conflict, validation or rate_limited; Entity and Key name
+// the object the request was about, if any.
+type Error struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Entity    string       `json:"entity,omitempty"`
+	Key       string       `json:"key,omitempty"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func NewUser(user *core.User) *User {
+	return &User{Nickname: user.Nickname, Fullname: user.Fullname, About: user.About, Email: user.Email}
+}
+
+func NewForum(forum *core.Forum) *Forum {
+	return &Forum{Slug: forum.Slug, Title: forum.Title, User: forum.User, Threads: forum.Threads, Posts: forum.Posts}
+}
+
+// NewThread converts thread. Without activity, e.g. for a thread deleted in
+// the meantime, it counts no posts and was last active when created.
+func NewThread(thread *core.Thread, activity *core.ThreadActivity) *Thread {
+	t := &Thread{
+		ID:           thread.ID,
+		Slug:         thread.Slug,
+		Forum:        thread.Forum,
+		Author:       thread.Author,
+		Title:        thread.Title,
+		Message:      thread.Message,
+		Votes:        thread.Votes,
+		Locked:       thread.Locked,
+		Created:      Time{thread.Created},
+		LastActivity: Time{thread.Created},
+	}
+	if activity != nil {
+		t.Posts = activity.Posts
+		t.LastActivity = Time{activity.LastActivity}
+	}
+	return t
+}
+
+func NewPost(post *core.Post, depth int64) *Post {
+	return &Post{
+		ID:       post.ID,
+		Parent:   post.Parent,
+		Depth:    depth,
+		Forum:    post.Forum,
+		Thread:   post.Thread,
+		Author:   post.Author,
+		Message:  post.Message,
+		IsEdited: post.IsEdited,
+		Created:  Time{post.Created},
+	}
+}
@@ -0,0 +1,231 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	openapierrors "github.com/go-openapi/errors"
+	"github.com/go-openapi/loads"
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+	"github.com/rinatkh/db_forum/internal/domain"
+)
+
+var pathParamRe = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Spec is an OpenAPI 2.0 document with its operations indexed by echo route,
+// e.g. "POST /api/forum/:slug/create".
+type Spec struct {
+	raw         []byte
+	operations  map[string]*Operation
+	errorSchema *spec.Schema
+	formats     strfmt.Registry
+}
+
+// Operation validates the requests and responses of one route.
+type Operation struct {
+	spec *Spec
+	op   *spec.Operation
+}
+
+// Load parses a YAML or JSON document and resolves its references.
+func Load(raw []byte) (*Spec, error) {
+	yamlDoc, err := swag.BytesToYAMLDoc(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse spec: %w", err)
+	}
+	jsonDoc, err := swag.YAMLToJSON(yamlDoc)
+	if err != nil {
+		return nil, fmt.Errorf("convert spec: %w", err)
+	}
+	doc, err := loads.Analyzed(jsonDoc, "2.0")
+	if err != nil {
+		return nil, fmt.Errorf("load spec: %w", err)
+	}
+	if doc, err = doc.Expanded(); err != nil {
+		return nil, fmt.Errorf("expand spec: %w", err)
+	}
+
+	s := &Spec{raw: raw, operations: make(map[string]*Operation), formats: strfmt.Default}
+	if errorSchema, ok := doc.Spec().Definitions["Error"]; ok {
+		s.errorSchema = &errorSchema
+	}
+	if doc.Spec().Paths == nil {
+		return s, nil
+	}
+	for path, item := range doc.Spec().Paths.Paths {
+		route := doc.BasePath() + pathParamRe.ReplaceAllString(path, ":$1")
+		for method, op := range map[string]*spec.Operation{
+			http.MethodGet:    item.Get,
+			http.MethodPost:   item.Post,
+			http.MethodPut:    item.Put,
+			http.MethodPatch:  item.Patch,
+			http.MethodDelete: item.Delete,
+		} {
+			if op != nil {
+				s.operations[method+" "+route] = &Operation{spec: s, op: op}
+			}
+		}
+	}
+	return s, nil
+}
+
+// Raw returns the document as it was loaded.
+func (s *Spec) Raw() []byte {
+	return s.raw
+}
+
+// Operation looks up the operation of an echo route.
+func (s *Spec) Operation(method, route string) (*Operation, bool) {
+	op, ok := s.operations[method+" "+route]
+	return op, ok
+}
+
+// ValidateRequest checks path and query parameters and the body against the
+// operation. param returns the value of a path parameter.
+func (o *Operation) ValidateRequest(param func(string) string, query url.Values, body []byte) []domain.FieldError {
+	var mismatches []domain.FieldError
+	for i := range o.op.Parameters {
+		p := &o.op.Parameters[i]
+		switch p.In {
+		case "path":
+			mismatches = append(mismatches, o.validateParam(p, []string{param(p.Name)})...)
+		case "query":
+			mismatches = append(mismatches, o.validateParam(p, query[p.Name])...)
+		case "body":
+			if len(body) == 0 {
+				if p.Required {
+					mismatches = append(mismatches, domain.FieldError{Field: "body", Message: "is required"})
+				}
+				continue
+			}
+			var data interface{}
+			if err := json.Unmarshal(body, &data); err != nil {
+				mismatches = append(mismatches, domain.FieldError{Field: "body", Message: err.Error()})
+				continue
+			}
+			if err := validate.AgainstSchema(p.Schema, data, o.spec.formats); err != nil {
+				mismatches = append(mismatches, fieldErrors("body", err)...)
+			}
+		}
+	}
+	return mismatches
+}
+
+func (o *Operation) validateParam(p *spec.Parameter, values []string) []domain.FieldError {
+	if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+		if p.Required {
+			return []domain.FieldError{{Field: p.Name, Message: "is required"}}
+		}
+		return nil
+	}
+
+	var data interface{}
+	var err error
+	if p.Type == "array" {
+		items := strings.Split(values[0], ",")
+		converted := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			var value interface{}
+			if p.Items != nil {
+				value, err = convert(p.Items.Type, item)
+			} else {
+				value = item
+			}
+			if err != nil {
+				break
+			}
+			converted = append(converted, value)
+		}
+		data = converted
+	} else {
+		data, err = convert(p.Type, values[0])
+	}
+	if err != nil {
+		return []domain.FieldError{{Field: p.Name, Message: fmt.Sprintf("must be of type %s", p.Type)}}
+	}
+
+	result := validate.NewParamValidator(p, o.spec.formats).Validate(data)
+	if result == nil {
+		return nil
+	}
+	var mismatches []domain.FieldError
+	for _, err := range result.Errors {
+		mismatches = append(mismatches, fieldErrors(p.Name, err)...)
+	}
+	return mismatches
+}
+
+// ValidateResponse checks a response body against the schema documented for
+// its status. Error statuses the operation does not list are checked against
+// the Error definition, as the server answers them the same way everywhere.
+func (o *Operation) ValidateResponse(status int, body []byte) []domain.FieldError {
+	var schema *spec.Schema
+	if response, ok := o.responses()[status]; ok {
+		schema = response.Schema
+	} else if o.op.Responses != nil && o.op.Responses.Default != nil {
+		schema = o.op.Responses.Default.Schema
+	} else if status >= http.StatusBadRequest && o.spec.errorSchema != nil {
+		schema = o.spec.errorSchema
+	} else {
+		return []domain.FieldError{{Field: "status", Message: fmt.Sprintf("%d is not documented", status)}}
+	}
+	if schema == nil {
+		return nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return []domain.FieldError{{Field: "body", Message: err.Error()}}
+	}
+	if err := validate.AgainstSchema(schema, data, o.spec.formats); err != nil {
+		return fieldErrors("body", err)
+	}
+	return nil
+}
+
+func (o *Operation) responses() map[int]spec.Response {
+	if o.op.Responses == nil {
+		return nil
+	}
+	return o.op.Responses.StatusCodeResponses
+}
+
+func convert(typ, value string) (interface{}, error) {
+	switch typ {
+	case "number":
+		return strconv.ParseFloat(value, 64)
+	case "integer":
+		return strconv.ParseInt(value, 10, 64)
+	case "boolean":
+		return strconv.ParseBool(value)
+	}
+	return value, nil
+}
+
+// fieldErrors flattens the errors of go-openapi into field errors; where
+// names the request or response part when the error does not name a field.
+func fieldErrors(where string, err error) []domain.FieldError {
+	switch e := err.(type) {
+	case *openapierrors.CompositeError:
+		var fields []domain.FieldError
+		for _, inner := range e.Errors {
+			fields = append(fields, fieldErrors(where, inner)...)
+		}
+		return fields
+	case *openapierrors.Validation:
+		field := strings.TrimPrefix(e.Name, ".")
+		if field == "" {
+			field = where
+		}
+		return []domain.FieldError{{Field: field, Message: e.Error()}}
+	}
+	return []domain.FieldError{{Field: where, Message: err.Error()}}
+}
@@ -0,0 +1,128 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rinatkh/db_forum/internal/auth"
+	"github.com/rinatkh/db_forum/internal/db"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+// Action is an operation that is restricted by role rather than by authorship
+// alone.
+type Action int
+
+const (
+	// EditPost and EditThread are allowed to the author and forum staff.
+	EditPost Action = iota
+	EditThread
+	// LockThread and PostToLockedThread are allowed to forum staff.
+	LockThread
+	PostToLockedThread
+	// ManageModerators and DeleteForum are allowed to the forum owner.
+	ManageModerators
+	DeleteForum
+	// ManageUsers and ClearData are allowed to site admins only; ClearData
+	// also to anonymous requests in open mode.
+	ManageUsers
+	ClearData
+)
+
+func (a Action) String() string {
+	switch a {
+	case EditPost:
+		return "edit post"
+	case EditThread:
+		return "edit thread"
+	case LockThread:
+		return "lock thread"
+	case PostToLockedThread:
+		return "post to locked thread"
+	case ManageModerators:
+		return "manage moderators"
+	case DeleteForum:
+		return "delete forum"
+	case ManageUsers:
+		return "manage users"
+	case ClearData:
+		return "clear data"
+	}
+	return "unknown"
+}
+
+// Target is what an action applies to. Forum scopes owner and moderator
+// roles and Author is the author of the post or thread, if any.
+type Target struct {
+	Forum  string
+	Author string
+}
+
+type Policy interface {
+	Authorize(ctx context.Context, action Action, target Target) error
+}
+
+type policyImpl struct {
+	log   *logrus.Entry
+	roles db.RoleRepository
+}
+
+// Authorize checks that the acting user may perform action on target. Site
+// admins may do anything, forum owners anything within their forum, and
+// moderators everything there except managing other moderators. Banned users
+// may do nothing. Internal callers may do anything. Anonymous requests in
+// open mode may edit posts and threads, as auth.Authorize lets them claim any
+// author, and clear the data as v1 clients expect, but nothing else that
+// takes a role.
+func (p *policyImpl) Authorize(ctx context.Context, action Action, target Target) error {
+	identity, ok := auth.FromContext(ctx)
+	if ok && identity.System {
+		return nil
+	}
+	if !ok && (action == EditPost || action == EditThread || action == ClearData) {
+		return nil
+	}
+	if !ok || identity.Nickname == "" {
+		return domain.Unauthorized("Authentication required to %s", action)
+	}
+
+	m, err := p.roles.GetMembership(ctx, identity.Nickname, target.Forum)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Unauthorized("Can't find user by nickname: %s", identity.Nickname)
+		}
+		return err
+	}
+
+	if m.Banned {
+		return domain.Forbidden("user", m.Nickname, "User is banned: %s", m.Nickname)
+	}
+	if m.Admin {
+		return nil
+	}
+
+	allowed := false
+	switch action {
+	case EditPost, EditThread:
+		allowed = m.Owner || m.Moderator || strings.EqualFold(m.Nickname, target.Author)
+	case LockThread, PostToLockedThread:
+		allowed = m.Owner || m.Moderator
+	case ManageModerators, DeleteForum:
+		allowed = m.Owner
+	}
+	if allowed {
+		return nil
+	}
+
+	if target.Forum != "" {
+		return domain.Forbidden("forum", target.Forum, "User %s may not %s in forum %s", m.Nickname, action, target.Forum)
+	}
+	return domain.Forbidden("user", m.Nickname, "User %s may not %s", m.Nickname, action)
+}
+
+func NewPolicy(log *logrus.Entry, roles db.RoleRepository) Policy {
+	return &policyImpl{log: log, roles: roles}
+}
@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Budget names a kind of write that is limited separately from the others.
+type Budget string
+
+const (
+	Threads Budget = "threads"
+	Posts   Budget = "posts"
+	Votes   Budget = "votes"
+)
+
+// Limit is a token bucket refilled at PerMinute tokens a minute and holding
+// at most Burst of them.
+type Limit struct {
+	PerMinute float64
+	Burst     int
+}
+
+type Limiter interface {
+	// Allow takes a token of budget from the bucket of key. It returns zero
+	// on success, or how long the caller has to wait for the next token.
+	Allow(budget Budget, key string) time.Duration
+	// AllowAll takes a token of budget from the bucket of every one of the
+	// distinct keys, or from none of them if one is empty. It returns an
+	// empty key on success, or the first key that ran dry and how long it
+	// has to wait.
+	AllowAll(budget Budget, keys []string) (string, time.Duration)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type limiterImpl struct {
+	mu        sync.Mutex
+	limits    map[Budget]Limit
+	buckets   map[Budget]map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// sweepInterval is how often buckets that have refilled completely, and so
+// are no different from new ones, are dropped.
+const sweepInterval = time.Minute
+
+func (l *limiterImpl) Allow(budget Budget, key string) time.Duration {
+	_, wait := l.AllowAll(budget, []string{key})
+	return wait
+}
+
+func (l *limiterImpl) AllowAll(budget Budget, keys []string) (string, time.Duration) {
+	limit, ok := l.limits[budget]
+	if !ok {
+		return "", 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	buckets := make([]*bucket, 0, len(keys))
+	for _, key := range keys {
+		b := l.refill(budget, limit, key, now)
+		if b.tokens < 1 {
+			return key, limit.wait(b)
+		}
+		buckets = append(buckets, b)
+	}
+	for _, b := range buckets {
+		b.tokens--
+	}
+	return "", 0
+}
+
+// refill returns the bucket of key with the tokens it gained since it was
+// last used.
+func (l *limiterImpl) refill(budget Budget, limit Limit, key string, now time.Time) *bucket {
+	burst := float64(limit.Burst)
+	b, ok := l.buckets[budget][key]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		l.buckets[budget][key] = b
+		return b
+	}
+	b.tokens += float64(now.Sub(b.updated)) * limit.PerMinute / float64(time.Minute)
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+	b.updated = now
+	return b
+}
+
+// wait is how long b takes to refill to one token.
+func (limit Limit) wait(b *bucket) time.Duration {
+	rate := limit.PerMinute / float64(time.Minute)
+	if rate <= 0 {
+		return sweepInterval
+	}
+	return time.Duration(math.Ceil((1 - b.tokens) / rate))
+}
+
+func (l *limiterImpl) sweep(now time.Time) {
+	l.lastSweep = now
+	for budget, buckets := range l.buckets {
+		limit := l.limits[budget]
+		for key, b := range buckets {
+			if b.tokens+float64(now.Sub(b.updated))*limit.PerMinute/float64(time.Minute) >= float64(limit.Burst) {
+				delete(buckets, key)
+			}
+		}
+	}
+}
+
+// New returns an in-memory limiter. Budgets missing from limits, or with a
+// burst below one, are not limited.
+func New(limits map[Budget]Limit) Limiter {
+	l := &limiterImpl{
+		limits:  make(map[Budget]Limit),
+		buckets: make(map[Budget]map[string]*bucket),
+		now:     time.Now,
+	}
+	for budget, limit := range limits {
+		if limit.Burst < 1 {
+			continue
+		}
+		l.limits[budget] = limit
+		l.buckets[budget] = make(map[string]*bucket)
+	}
+	l.lastSweep = l.now()
+	return l
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter(limits map[Budget]Limit) (*limiterImpl, *clock) {
+	c := &clock{t: time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)}
+	l := New(limits).(*limiterImpl)
+	l.now = c.now
+	l.lastSweep = c.now()
+	return l, c
+}
+
+func TestAllowAll(t *testing.T) {
+	l, _ := newTestLimiter(map[Budget]Limit{Posts: {PerMinute: 1, Burst: 2}})
+
+	if key, wait := l.AllowAll(Posts, []string{"user", "forum"}); key != "" || wait != 0 {
+		t.Fatalf("AllowAll() = %q, %v, want success", key, wait)
+	}
+	if key, _ := l.AllowAll(Posts, []string{"user"}); key != "" {
+		t.Fatalf("AllowAll() refused %q with a token left", key)
+	}
+
+	// user is empty now, so forum must keep its last token.
+	key, wait := l.AllowAll(Posts, []string{"forum", "user"})
+	if key != "user" || wait != time.Minute {
+		t.Fatalf("AllowAll() = %q, %v, want %q, %v", key, wait, "user", time.Minute)
+	}
+	if tokens := l.buckets[Posts]["forum"].tokens; tokens != 1 {
+		t.Errorf("forum has %v tokens after a refused call, want 1", tokens)
+	}
+	if key, _ := l.AllowAll(Posts, []string{"forum"}); key != "" {
+		t.Errorf("AllowAll() refused %q with a token left", key)
+	}
+}
+
+func TestAllowAllUnlimited(t *testing.T) {
+	l, _ := newTestLimiter(map[Budget]Limit{Posts: {PerMinute: 1, Burst: 0}})
+	for i := 0; i < 10; i++ {
+		if key, wait := l.AllowAll(Posts, []string{"user"}); key != "" || wait != 0 {
+			t.Fatalf("AllowAll() = %q, %v on an unlimited budget", key, wait)
+		}
+		if wait := l.Allow(Threads, "user"); wait != 0 {
+			t.Fatalf("Allow() = %v on a missing budget", wait)
+		}
+	}
+}
+
+func TestRefill(t *testing.T) {
+	l, c := newTestLimiter(map[Budget]Limit{Votes: {PerMinute: 2, Burst: 2}})
+
+	for i := 0; i < 2; i++ {
+		if wait := l.Allow(Votes, "user"); wait != 0 {
+			t.Fatalf("Allow() #%d = %v, want 0", i, wait)
+		}
+	}
+	if wait := l.Allow(Votes, "user"); wait != 30*time.Second {
+		t.Fatalf("Allow() on an empty bucket = %v, want 30s", wait)
+	}
+
+	c.advance(15 * time.Second)
+	if wait := l.Allow(Votes, "user"); wait != 15*time.Second {
+		t.Fatalf("Allow() half way to a token = %v, want 15s", wait)
+	}
+	c.advance(15 * time.Second)
+	if wait := l.Allow(Votes, "user"); wait != 0 {
+		t.Fatalf("Allow() after a refill = %v, want 0", wait)
+	}
+
+	// The bucket holds no more than Burst tokens however long it idles.
+	c.advance(time.Hour)
+	for i := 0; i < 2; i++ {
+		if wait := l.Allow(Votes, "user"); wait != 0 {
+			t.Fatalf("Allow() #%d after idling = %v, want 0", i, wait)
+		}
+	}
+	if wait := l.Allow(Votes, "user"); wait == 0 {
+		t.Fatal("Allow() took more than Burst tokens")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	l, c := newTestLimiter(map[Budget]Limit{Posts: {PerMinute: 1, Burst: 2}})
+
+	l.Allow(Posts, "idle")
+	c.advance(30 * time.Second)
+	l.Allow(Posts, "busy")
+	l.Allow(Posts, "busy")
+
+	// idle has refilled completely by now, busy has not.
+	c.advance(sweepInterval)
+	l.Allow(Posts, "other")
+	if _, ok := l.buckets[Posts]["idle"]; ok {
+		t.Error("sweep kept a full bucket")
+	}
+	if _, ok := l.buckets[Posts]["busy"]; !ok {
+		t.Error("sweep dropped a bucket that is not full")
+	}
+
+	// Sweeps wait for the interval to pass again.
+	c.advance(time.Hour)
+	l.lastSweep = c.now()
+	l.Allow(Posts, "another")
+	if _, ok := l.buckets[Posts]["busy"]; !ok {
+		t.Error("sweep ran before the interval passed")
+	}
+}
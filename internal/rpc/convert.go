@@ -0,0 +1,78 @@
+package rpc
+
+import (
+	"github.com/rinatkh/db_forum/internal/model/core"
+	forumv1 "github.com/rinatkh/db_forum/proto/forum/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func userMessage(user *core.User) *forumv1.User {
+	if user == nil {
+		return nil
+	}
+	return &forumv1.User{Nickname: user.Nickname, Fullname: user.Fullname, About: user.About, Email: user.Email}
+}
+
+func userMessages(users []*core.User) []*forumv1.User {
+	messages := make([]*forumv1.User, 0, len(users))
+	for _, user := range users {
+		messages = append(messages, userMessage(user))
+	}
+	return messages
+}
+
+func forumMessage(forum *core.Forum) *forumv1.Forum {
+	if forum == nil {
+		return nil
+	}
+	return &forumv1.Forum{Slug: forum.Slug, Title: forum.Title, User: forum.User, Posts: forum.Posts, Threads: forum.Threads}
+}
+
+func threadMessage(thread *core.Thread) *forumv1.Thread {
+	if thread == nil {
+		return nil
+	}
+	return &forumv1.Thread{
+		Id:      thread.ID,
+		Slug:    thread.Slug,
+		Title:   thread.Title,
+		Message: thread.Message,
+		Author:  thread.Author,
+		Forum:   thread.Forum,
+		Votes:   thread.Votes,
+		Created: timestamppb.New(thread.Created),
+		Locked:  thread.Locked,
+	}
+}
+
+func threadMessages(threads []*core.Thread) []*forumv1.Thread {
+	messages := make([]*forumv1.Thread, 0, len(threads))
+	for _, thread := range threads {
+		messages = append(messages, threadMessage(thread))
+	}
+	return messages
+}
+
+func postMessage(post *core.Post) *forumv1.Post {
+	if post == nil {
+		return nil
+	}
+	return &forumv1.Post{
+		Id:       post.ID,
+		Parent:   post.Parent,
+		Author:   post.Author,
+		Message:  post.Message,
+		IsEdited: post.IsEdited,
+		Forum:    post.Forum,
+		Thread:   post.Thread,
+		Created:  timestamppb.New(post.Created),
+	}
+}
+
+func postMessages(posts []*core.Post) []*forumv1.Post {
+	messages := make([]*forumv1.Post, 0, len(posts))
+	for _, post := range posts {
+		messages = append(messages, postMessage(post))
+	}
+	return messages
+}
@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/logging"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// pgQueryCanceled is the SQLSTATE of statements stopped by statement_timeout.
+const pgQueryCanceled = "57014"
+
+var domainCodes = map[domain.Kind]codes.Code{
+	domain.KindNotFound:     codes.NotFound,
+	domain.KindConflict:     codes.AlreadyExists,
+	domain.KindValidation:   codes.InvalidArgument,
+	domain.KindForbidden:    codes.PermissionDenied,
+	domain.KindUnauthorized: codes.Unauthenticated,
+	domain.KindRateLimited:  codes.ResourceExhausted,
+}
+
+// toStatus turns err into the status reported to the client, the way the
+// REST error handler picks a response: domain errors keep their message and
+// carry details, unexpected errors are logged and hidden.
+func (s *Server) toStatus(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	if domainErr, ok := domain.As(err); ok {
+		code, ok := domainCodes[domainErr.Kind]
+		if !ok {
+			code = codes.Internal
+		}
+		st := status.New(code, domainErr.Message)
+		var details []protoiface.MessageV1
+		switch {
+		case len(domainErr.Fields) > 0:
+			badRequest := &errdetails.BadRequest{}
+			for _, field := range domainErr.Fields {
+				badRequest.FieldViolations = append(badRequest.FieldViolations,
+					&errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Message})
+			}
+			details = append(details, badRequest)
+		case domainErr.Kind == domain.KindRateLimited:
+			details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(domainErr.RetryAfter)})
+		case domainErr.Entity != "" && domainErr.Key != "":
+			details = append(details, &errdetails.ResourceInfo{ResourceType: domainErr.Entity, ResourceName: domainErr.Key})
+		}
+		if len(details) > 0 {
+			if detailed, err := st.WithDetails(details...); err == nil {
+				st = detailed
+			}
+		}
+		return st.Err()
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &pgErr) && pgErr.Code == pgQueryCanceled) {
+		logging.FromContext(ctx, s.log).Warnf("request timed out: %s", err)
+		return status.Error(codes.DeadlineExceeded, "request timed out")
+	}
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, "request canceled")
+	}
+
+	logging.FromContext(ctx, s.log).Errorf("unexpected error: %s", err)
+	return status.Error(codes.Internal, "Internal Server Error")
+}
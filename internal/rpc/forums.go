@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	forumv1 "github.com/rinatkh/db_forum/proto/forum/v1"
+)
+
+// defaultLimit is the page size of listings that do not ask for one, as in
+// the REST API.
+const defaultLimit = 100
+
+type forumServer struct {
+	forumv1.UnimplementedForumServiceServer
+	*Server
+}
+
+func (s *forumServer) CreateForum(ctx context.Context, in *forumv1.CreateForumRequest) (*forumv1.Forum, error) {
+	request := &dto.CreateForumRequest{Slug: in.GetSlug(), Title: in.GetTitle(), User: in.GetUser()}
+	if err := s.validate(request); err != nil {
+		return nil, err
+	}
+	forum, err := s.registry.ForumService.CreateForum(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return forumMessage(forum), nil
+}
+
+func (s *forumServer) GetForum(ctx context.Context, in *forumv1.GetForumRequest) (*forumv1.Forum, error) {
+	request := &dto.GetForumRequest{Slug: in.GetSlug()}
+	if err := s.validate(request); err != nil {
+		return nil, err
+	}
+	forum, err := s.registry.ForumService.GetForum(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return forumMessage(forum), nil
+}
+
+func (s *forumServer) ListForumUsers(ctx context.Context, in *forumv1.ListForumUsersRequest) (*forumv1.ListForumUsersResponse, error) {
+	request := &dto.GetForumUsersRequest{Slug: in.GetSlug(), Limit: in.GetLimit(), Since: in.GetSince(), Desc: in.GetDesc()}
+	if request.Limit == 0 {
+		request.Limit = defaultLimit
+	}
+	if err := s.validate(request); err != nil {
+		return nil, err
+	}
+	users, err := s.registry.ForumService.GetForumUsers(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return &forumv1.ListForumUsersResponse{Users: userMessages(users)}, nil
+}
+
+func (s *forumServer) ListForumThreads(ctx context.Context, in *forumv1.ListForumThreadsRequest) (*forumv1.ListForumThreadsResponse, error) {
+	request := &dto.GetForumThreadsRequest{Slug: in.GetSlug(), Limit: in.GetLimit(), Desc: in.GetDesc()}
+	if request.Limit == 0 {
+		request.Limit = defaultLimit
+	}
+	if in.GetSince() != nil {
+		request.Since = in.GetSince().AsTime().Format(time.RFC3339Nano)
+	}
+	if err := s.validate(request); err != nil {
+		return nil, err
+	}
+	threads, err := s.registry.ForumService.GetForumThreads(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return &forumv1.ListForumThreadsResponse{Threads: threadMessages(threads)}, nil
+}
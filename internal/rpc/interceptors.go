@@ -0,0 +1,211 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/rinatkh/db_forum/internal/auth"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/logging"
+	"github.com/rinatkh/db_forum/internal/ratelimit"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const requestIDKey = "x-request-id"
+
+// method describes how calls of one RPC are bounded.
+type method struct {
+	timeout time.Duration
+	budget  ratelimit.Budget
+}
+
+// methodOptions mirrors the timeouts and rate limits of the REST routes.
+// Methods missing here are reads.
+func (s *Server) methodOptions() map[string]method {
+	write := method{timeout: s.opts.WriteTimeout}
+	return map[string]method{
+		"/forum.v1.UserService/CreateUser":     write,
+		"/forum.v1.UserService/UpdateUser":     write,
+		"/forum.v1.ForumService/CreateForum":   write,
+		"/forum.v1.ThreadService/CreateThread": {timeout: s.opts.WriteTimeout, budget: ratelimit.Threads},
+		"/forum.v1.ThreadService/UpdateThread": write,
+		"/forum.v1.ThreadService/Vote":         {timeout: s.opts.WriteTimeout, budget: ratelimit.Votes},
+		"/forum.v1.PostService/CreatePosts":    {timeout: s.opts.BulkTimeout, budget: ratelimit.Posts},
+		"/forum.v1.PostService/UpdatePost":     write,
+	}
+}
+
+func (s *Server) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	ctx, cancel, err := s.begin(ctx, info.FullMethod)
+	defer cancel()
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			err = s.recovered(ctx, r)
+		}
+		s.finish(ctx, info.FullMethod, start, err)
+	}()
+	if err != nil {
+		return nil, err
+	}
+	resp, err = handler(ctx, req)
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+	return resp, nil
+}
+
+func (s *Server) streamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	ctx, cancel, err := s.begin(stream.Context(), info.FullMethod)
+	defer cancel()
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			err = s.recovered(ctx, r)
+		}
+		s.finish(ctx, info.FullMethod, start, err)
+	}()
+	if err != nil {
+		return err
+	}
+	if err = handler(srv, &contextStream{ServerStream: stream, ctx: ctx}); err != nil {
+		return s.toStatus(ctx, err)
+	}
+	return nil
+}
+
+// begin prepares the context of a call: a logger tagged with the request id,
+// the identity of the caller and the deadline of the method. It fails calls
+// with bad credentials or over their rate limit.
+func (s *Server) begin(ctx context.Context, fullMethod string) (context.Context, context.CancelFunc, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestID := first(md, requestIDKey)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+	fields := logrus.Fields{"request_id": requestID, "grpc_method": fullMethod}
+	if span := trace.SpanFromContext(ctx).SpanContext(); span.IsValid() {
+		fields["trace_id"] = span.TraceID().String()
+	}
+	ctx = logging.WithLogger(ctx, s.log.WithFields(fields))
+
+	m, ok := s.methods[fullMethod]
+	if !ok {
+		m.timeout = s.opts.ReadTimeout
+	}
+	cancel := context.CancelFunc(func() {})
+	if m.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+	}
+
+	var err error
+	if ctx, err = s.authenticate(ctx, md); err != nil {
+		return ctx, cancel, s.toStatus(ctx, err)
+	}
+	if m.budget != "" && s.opts.Limiter != nil {
+		ip := peerIP(ctx)
+		if wait := s.opts.Limiter.Allow(m.budget, "ip:"+ip); wait > 0 {
+			err := domain.RateLimited(wait, "Too many %s from %s, retry in %s", m.budget, ip, wait.Round(time.Second))
+			return ctx, cancel, s.toStatus(ctx, err)
+		}
+	}
+	return ctx, cancel, nil
+}
+
+// authenticate attaches the identity of the caller to ctx, from the trusted
+// metadata key or a bearer token, like the REST auth middleware. Without
+// Auth, anonymous callers are left without an identity.
+func (s *Server) authenticate(ctx context.Context, md metadata.MD) (context.Context, error) {
+	const scheme = "bearer "
+	identity := &auth.Identity{}
+
+	if nickname := first(md, s.opts.TrustedHeader); s.opts.TrustedHeader != "" && nickname != "" {
+		identity.Nickname = nickname
+	} else if header := first(md, "authorization"); header != "" {
+		if len(header) <= len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) {
+			return ctx, domain.Unauthorized("Unsupported authorization scheme")
+		}
+		nickname, err := s.registry.AuthService.Authenticate(ctx, strings.TrimSpace(header[len(scheme):]))
+		if err != nil {
+			return ctx, err
+		}
+		identity.Nickname = nickname
+	}
+	if identity.Nickname == "" && !s.opts.Auth {
+		return ctx, nil
+	}
+	if identity.Nickname != "" {
+		ctx = logging.WithLogger(ctx, logging.FromContext(ctx, s.log).WithField("user", identity.Nickname))
+	}
+	return auth.WithIdentity(ctx, identity), nil
+}
+
+func (s *Server) recovered(ctx context.Context, r interface{}) error {
+	logging.FromContext(ctx, s.log).Errorf("panic: %v\n%s", r, debug.Stack())
+	return status.Error(codes.Internal, "Internal Server Error")
+}
+
+func (s *Server) finish(ctx context.Context, fullMethod string, start time.Time, err error) {
+	code := status.Code(err)
+	log := logging.FromContext(ctx, s.log).WithFields(logrus.Fields{
+		"code":           code.String(),
+		"execution_time": time.Since(start).String(),
+	})
+	if code == codes.Internal || code == codes.Unknown {
+		log.Infof("[error]: %v", err)
+		return
+	}
+	log.Debug(fullMethod)
+}
+
+// contextStream replaces the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func first(md metadata.MD, key string) string {
+	if key == "" {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return p.Addr.String()
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
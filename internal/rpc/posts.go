@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	forumv1 "github.com/rinatkh/db_forum/proto/forum/v1"
+)
+
+var postSorts = map[forumv1.PostSort]string{
+	forumv1.PostSort_POST_SORT_UNSPECIFIED: "flat",
+	forumv1.PostSort_POST_SORT_FLAT:        "flat",
+	forumv1.PostSort_POST_SORT_TREE:        "tree",
+	forumv1.PostSort_POST_SORT_PARENT_TREE: "parent_tree",
+}
+
+type postServer struct {
+	forumv1.UnimplementedPostServiceServer
+	*Server
+}
+
+func (s *postServer) CreatePosts(ctx context.Context, in *forumv1.CreatePostsRequest) (*forumv1.CreatePostsResponse, error) {
+	request := make([]*dto.Post, 0, len(in.GetPosts()))
+	for _, post := range in.GetPosts() {
+		request = append(request, &dto.Post{Parent: post.GetParent(), Author: post.GetAuthor(), Message: post.GetMessage()})
+	}
+	if err := s.validate(request); err != nil {
+		return nil, err
+	}
+	posts, err := s.registry.PostsService.CreatePosts(ctx, in.GetSlugOrId(), request)
+	if err != nil {
+		return nil, err
+	}
+	return &forumv1.CreatePostsResponse{Posts: postMessages(posts)}, nil
+}
+
+// ListPosts sends the posts one message each, in the order the REST listing
+// returns them.
+func (s *postServer) ListPosts(in *forumv1.ListPostsRequest, stream forumv1.PostService_ListPostsServer) error {
+	sort, ok := postSorts[in.GetSort()]
+	if !ok {
+		sort = fmt.Sprint(in.GetSort())
+	}
+	request := &dto.GetPostsRequest{SlugOrID: in.GetSlugOrId(), Sort: sort, Since: in.Since, Limit: in.GetLimit(), Desc: in.GetDesc()}
+	if request.Limit == 0 {
+		request.Limit = defaultLimit
+	}
+	if err := s.validate(request); err != nil {
+		return err
+	}
+
+	since := int64(-1)
+	if request.Since != nil {
+		since = *request.Since
+	}
+	posts, err := s.registry.PostsService.GetPosts(stream.Context(), request.SlugOrID, request.Sort, since, request.Desc, request.Limit)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		if err := stream.Send(postMessage(post)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *postServer) GetPost(ctx context.Context, in *forumv1.GetPostRequest) (*forumv1.PostDetails, error) {
+	request := &dto.GetPostDetailsRequest{ID: in.GetId(), Related: strings.Join(in.GetRelated(), ",")}
+	if err := s.validate(request); err != nil {
+		return nil, err
+	}
+	details, err := s.registry.PostsService.GetPostDetails(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return &forumv1.PostDetails{
+		Post:   postMessage(details.Post),
+		Author: userMessage(details.Author),
+		Thread: threadMessage(details.Thread),
+		Forum:  forumMessage(details.Forum),
+	}, nil
+}
+
+func (s *postServer) UpdatePost(ctx context.Context, in *forumv1.UpdatePostRequest) (*forumv1.Post, error) {
+	request := &dto.EditPostRequest{ID: in.GetId(), Message: in.GetMessage()}
+	if err := s.validate(request); err != nil {
+		return nil, err
+	}
+	post, err := s.registry.PostsService.EditPost(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return postMessage(post), nil
+}
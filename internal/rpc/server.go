@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/rinatkh/db_forum/internal/ratelimit"
+	"github.com/rinatkh/db_forum/internal/service"
+	forumv1 "github.com/rinatkh/db_forum/proto/forum/v1"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Validator checks request DTOs the way the REST binder does.
+type Validator interface {
+	Validate(i interface{}) error
+}
+
+// Options configure the server the way the REST API is configured.
+type Options struct {
+	// Auth requires credentials like the REST auth middleware does in token
+	// mode; TrustedHeader names the metadata key of a proxy-asserted user,
+	// which is honoured in either mode.
+	Auth          bool
+	TrustedHeader string
+	// Limiter applies the per-IP write budgets of the REST API.
+	Limiter ratelimit.Limiter
+	// Timeouts bound reads, writes and bulk post creation like the REST
+	// request timeouts; zero disables one.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	BulkTimeout  time.Duration
+	// Reflection registers the reflection service for tools like grpcurl.
+	Reflection bool
+}
+
+// Server serves the forum gRPC API through the service registry.
+type Server struct {
+	log       *logrus.Entry
+	registry  *service.Registry
+	validator Validator
+	opts      Options
+	methods   map[string]method
+	server    *grpc.Server
+}
+
+func (s *Server) validate(request interface{}) error {
+	if s.validator == nil {
+		return nil
+	}
+	return s.validator.Validate(request)
+}
+
+// Serve accepts connections on lis until Shutdown is called.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// Shutdown stops accepting connections and waits for running calls to
+// finish, cancelling those still running when ctx is done.
+func (s *Server) Shutdown(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.server.Stop()
+	}
+}
+
+func NewServer(log *logrus.Entry, registry *service.Registry, validator Validator, opts Options) *Server {
+	s := &Server{
+		log:       log,
+		registry:  registry,
+		validator: validator,
+		opts:      opts,
+	}
+	s.methods = s.methodOptions()
+	s.server = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor),
+		grpc.ChainStreamInterceptor(s.streamInterceptor),
+	)
+	forumv1.RegisterUserServiceServer(s.server, &userServer{Server: s})
+	forumv1.RegisterForumServiceServer(s.server, &forumServer{Server: s})
+	forumv1.RegisterThreadServiceServer(s.server, &threadServer{Server: s})
+	forumv1.RegisterPostServiceServer(s.server, &postServer{Server: s})
+	if opts.Reflection {
+		reflection.Register(s.server)
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	forumv1 "github.com/rinatkh/db_forum/proto/forum/v1"
+)
+
+type threadServer struct {
+	forumv1.UnimplementedThreadServiceServer
+	*Server
+}
+
+func (s *threadServer) CreateThread(ctx context.Context, in *forumv1.CreateThreadRequest) (*forumv1.Thread, error) {
+	request := &dto.CreateThreadRequest{
+		Forum:   in.GetForum(),
+		Author:  in.GetAuthor(),
+		Title:   in.GetTitle(),
+		Message: in.GetMessage(),
+		Slug:    in.GetSlug(),
+	}
+	if in.GetCreated() != nil {
+		request.Created = in.GetCreated().AsTime()
+	}
+	if err := s.validate(request); err != nil {
+		return nil, err
+	}
+	thread, err := s.registry.ThreadService.CreateThread(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return threadMessage(thread), nil
+}
+
+func (s *threadServer) GetThread(ctx context.Context, in *forumv1.GetThreadRequest) (*forumv1.Thread, error) {
+	thread, err := s.registry.ThreadService.GetThread(ctx, in.GetSlugOrId())
+	if err != nil {
+		return nil, err
+	}
+	return threadMessage(thread), nil
+}
+
+func (s *threadServer) UpdateThread(ctx context.Context, in *forumv1.UpdateThreadRequest) (*forumv1.Thread, error) {
+	request := &dto.EditThreadRequest{Title: in.GetTitle(), Message: in.GetMessage()}
+	thread, err := s.registry.ThreadService.EditThread(ctx, in.GetSlugOrId(), request)
+	if err != nil {
+		return nil, err
+	}
+	return threadMessage(thread), nil
+}
+
+func (s *threadServer) Vote(ctx context.Context, in *forumv1.VoteRequest) (*forumv1.Thread, error) {
+	request := &dto.EditVoteRequest{Nickname: in.GetNickname(), Voice: int64(in.GetVoice())}
+	if err := s.validate(request); err != nil {
+		return nil, err
+	}
+	thread, err := s.registry.ThreadService.CountVote(ctx, in.GetSlugOrId(), request)
+	if err != nil {
+		return nil, err
+	}
+	return threadMessage(thread), nil
+}
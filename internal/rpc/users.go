@@ -0,0 +1,60 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	forumv1 "github.com/rinatkh/db_forum/proto/forum/v1"
+)
+
+type userServer struct {
+	forumv1.UnimplementedUserServiceServer
+	*Server
+}
+
+func (s *userServer) CreateUser(ctx context.Context, in *forumv1.CreateUserRequest) (*forumv1.User, error) {
+	request := &dto.CreateUserRequest{
+		Nickname: in.GetNickname(),
+		Fullname: in.GetFullname(),
+		About:    in.GetAbout(),
+		Email:    in.GetEmail(),
+		Password: in.GetPassword(),
+	}
+	if err := s.validate(request); err != nil {
+		return nil, err
+	}
+	user, err := s.registry.UserService.CreateUser(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return userMessage(user), nil
+}
+
+func (s *userServer) GetUser(ctx context.Context, in *forumv1.GetUserRequest) (*forumv1.User, error) {
+	request := &dto.GetUserProfileRequest{Nickname: in.GetNickname()}
+	if err := s.validate(request); err != nil {
+		return nil, err
+	}
+	user, err := s.registry.UserService.GetUserProfile(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return userMessage(user), nil
+}
+
+func (s *userServer) UpdateUser(ctx context.Context, in *forumv1.UpdateUserRequest) (*forumv1.User, error) {
+	request := &dto.EditUserProfileRequest{
+		Nickname: in.GetNickname(),
+		Fullname: in.GetFullname(),
+		About:    in.GetAbout(),
+		Email:    in.GetEmail(),
+	}
+	if err := s.validate(request); err != nil {
+		return nil, err
+	}
+	user, err := s.registry.UserService.EditUserProfile(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return userMessage(user), nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/rinatkh/db_forum/internal/auth"
+	"github.com/rinatkh/db_forum/internal/db"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	"github.com/sirupsen/logrus"
+)
+
+type AuthService interface {
+	Login(ctx context.Context, request *dto.LoginRequest) (*dto.LoginResponse, error)
+	SetPassword(ctx context.Context, request *dto.SetPasswordRequest) (*dto.LoginResponse, error)
+	CreateAPIToken(ctx context.Context, nickname string, name string) (string, error)
+	Authenticate(ctx context.Context, token string) (string, error)
+}
+
+type authServiceImpl struct {
+	log    *logrus.Entry
+	db     *db.Repository
+	signer *auth.Signer
+}
+
+// Login exchanges a nickname and password for a signed token. Unknown users
+// and wrong passwords are reported alike.
+func (svc *authServiceImpl) Login(ctx context.Context, request *dto.LoginRequest) (*dto.LoginResponse, error) {
+	hash, err := svc.db.AuthRepository.GetPasswordHash(ctx, request.Nickname)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		auth.RejectPassword(request.Password)
+		return nil, domain.Unauthorized("Wrong nickname or password")
+	}
+	if !auth.CheckPassword(hash, request.Password) {
+		return nil, domain.Unauthorized("Wrong nickname or password")
+	}
+
+	user, err := svc.db.UserRepository.GetUserByNickname(ctx, request.Nickname)
+	if err != nil {
+		return nil, err
+	}
+	token, expires := svc.signer.Sign(user.Nickname)
+	return &dto.LoginResponse{Token: token, ExpiresAt: expires}, nil
+}
+
+// SetPassword replaces the password of a user and logs them in. Only the
+// user may do so, even in open mode; initial passwords are given on creation.
+func (svc *authServiceImpl) SetPassword(ctx context.Context, request *dto.SetPasswordRequest) (*dto.LoginResponse, error) {
+	if err := auth.Authenticated(ctx, request.Nickname); err != nil {
+		return nil, err
+	}
+	user, err := svc.db.UserRepository.GetUserByNickname(ctx, request.Nickname)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("user", request.Nickname, "Can't find user by nickname: %s", request.Nickname)
+		}
+		return nil, err
+	}
+
+	hash, err := auth.HashPassword(request.Password)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.db.AuthRepository.SetPasswordHash(ctx, user.Nickname, hash); err != nil {
+		return nil, err
+	}
+	token, expires := svc.signer.Sign(user.Nickname)
+	return &dto.LoginResponse{Token: token, ExpiresAt: expires}, nil
+}
+
+// CreateAPIToken issues a long-lived token for scripts; it is shown only once.
+func (svc *authServiceImpl) CreateAPIToken(ctx context.Context, nickname string, name string) (string, error) {
+	user, err := svc.db.UserRepository.GetUserByNickname(ctx, nickname)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", domain.NotFound("user", nickname, "Can't find user by nickname: %s", nickname)
+		}
+		return "", err
+	}
+
+	token, hash, err := auth.NewAPIToken()
+	if err != nil {
+		return "", err
+	}
+	if err := svc.db.AuthRepository.CreateAPIToken(ctx, user.Nickname, hash, name); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Authenticate returns the nickname a signed or API token belongs to.
+func (svc *authServiceImpl) Authenticate(ctx context.Context, token string) (string, error) {
+	if auth.IsSigned(token) {
+		nickname, err := svc.signer.Verify(token)
+		if err != nil {
+			return "", domain.Unauthorized("Invalid or expired token")
+		}
+		return nickname, nil
+	}
+
+	nickname, err := svc.db.AuthRepository.GetAPITokenOwner(ctx, auth.HashAPIToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", domain.Unauthorized("Invalid or expired token")
+		}
+		return "", err
+	}
+	return nickname, nil
+}
+
+func NewAuthService(log *logrus.Entry, db *db.Repository, signer *auth.Signer) AuthService {
+	return &authServiceImpl{log: log, db: db, signer: signer}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/rinatkh/db_forum/internal/db"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+)
+
+// cursor is the decoded form of the opaque page tokens handed to clients. It
+// names the listing it belongs to and carries the sort key and id of the row
+// the next page starts after, plus the order the listing is read in.
+type cursor struct {
+	Listing  string `json:"l"`
+	Key      string `json:"k,omitempty"`
+	ID       int64  `json:"i,omitempty"`
+	Desc     bool   `json:"d,omitempty"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+func (c *cursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func (c *cursor) keyset() *db.Keyset {
+	if c == nil {
+		return nil
+	}
+	return &db.Keyset{Key: c.Key, ID: c.ID, Backward: c.Backward}
+}
+
+// keyFormat is the type of the sort key in the cursors of a listing.
+type keyFormat int
+
+const (
+	textKey keyFormat = iota
+	// timeKey keys are timestamps in time.RFC3339Nano.
+	timeKey
+)
+
+// decodeCursor parses a token of the given listing; an empty token starts
+// from the first page. The key is checked against format, so that a tampered
+// token is refused here rather than by the database.
+func decodeCursor(token, listing string, format keyFormat) (*cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalidCursor("is malformed")
+	}
+	c := &cursor{}
+	if err := json.Unmarshal(raw, c); err != nil {
+		return nil, invalidCursor("is malformed")
+	}
+	if c.Listing != listing {
+		return nil, invalidCursor("belongs to another listing")
+	}
+	if format == timeKey {
+		if _, err := time.Parse(time.RFC3339Nano, c.Key); err != nil {
+			return nil, invalidCursor("is malformed")
+		}
+	}
+	return c, nil
+}
+
+func invalidCursor(message string) error {
+	return domain.InvalidFields(domain.FieldError{Field: "cursor", Message: message})
+}
+
+// paginate turns one page read from the repository into a dto.Page. The page
+// was fetched with limit+1 rows so that the extra row, found at the far end
+// from the cursor, tells whether another page follows in that direction.
+// position returns the sort key and id a cursor next to an item resumes from.
+func paginate[T any](items []T, limit int64, from *cursor, listing string, desc bool, position func(T) (string, int64)) *dto.Page[T] {
+	backward := from != nil && from.Backward
+	more := int64(len(items)) > limit
+	if more {
+		if backward {
+			items = items[1:]
+		} else {
+			items = items[:limit]
+		}
+	}
+
+	hasNext, hasPrev := more, from != nil
+	if backward {
+		hasNext, hasPrev = true, more
+	}
+
+	if items == nil {
+		items = []T{}
+	}
+	page := &dto.Page[T]{Items: items}
+	at := func(key string, id int64, backward bool) string {
+		return (&cursor{Listing: listing, Key: key, ID: id, Desc: desc, Backward: backward}).encode()
+	}
+	if len(items) == 0 {
+		// Nothing left on this side of the cursor; only offer the way back.
+		if backward {
+			page.NextCursor = at(from.Key, from.ID, false)
+		} else if from != nil {
+			page.PrevCursor = at(from.Key, from.ID, true)
+		}
+		return page
+	}
+	if hasNext {
+		key, id := position(items[len(items)-1])
+		page.NextCursor = at(key, id, false)
+	}
+	if hasPrev {
+		key, id := position(items[0])
+		page.PrevCursor = at(key, id, true)
+	}
+	return page
+}
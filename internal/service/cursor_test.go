@@ -0,0 +1,108 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/rinatkh/db_forum/internal/domain"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	valid := &cursor{Listing: "threads", Key: "2022-03-01T10:00:00.5Z", ID: 3, Desc: true, Backward: true}
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name    string
+		token   string
+		listing string
+		format  keyFormat
+		want    *cursor
+		invalid bool
+	}{
+		{name: "empty", token: "", listing: "threads", format: timeKey},
+		{name: "time key", token: valid.encode(), listing: "threads", format: timeKey, want: valid},
+		{name: "text key", token: (&cursor{Listing: "users", Key: "a.b"}).encode(), listing: "users", format: textKey, want: &cursor{Listing: "users", Key: "a.b"}},
+		{name: "foreign listing", token: valid.encode(), listing: "posts", format: timeKey, invalid: true},
+		{name: "not base64", token: "%%%", listing: "threads", format: timeKey, invalid: true},
+		{name: "not json", token: raw("threads"), listing: "threads", format: timeKey, invalid: true},
+		{name: "tampered key", token: raw(`{"l":"threads","k":"1; DROP TABLE Threads","i":3}`), listing: "threads", format: timeKey, invalid: true},
+		{name: "missing key", token: raw(`{"l":"threads","i":3}`), listing: "threads", format: timeKey, invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.token, tt.listing, tt.format)
+			if tt.invalid {
+				var derr *domain.Error
+				if !errors.As(err, &derr) || derr.Kind != domain.KindValidation || len(derr.Fields) != 1 || derr.Fields[0].Field != "cursor" {
+					t.Fatalf("decodeCursor() error = %v, want an invalid cursor", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeCursor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	position := func(i int) (string, int64) { return strconv.Itoa(i), int64(i) }
+	at := func(id int64, desc, backward bool) *cursor {
+		return &cursor{Listing: "posts", Key: strconv.FormatInt(id, 10), ID: id, Desc: desc, Backward: backward}
+	}
+
+	tests := []struct {
+		name       string
+		items      []int
+		from       *cursor
+		desc       bool
+		want       []int
+		next, prev *cursor
+	}{
+		{name: "single page", items: []int{1, 2}, want: []int{1, 2}},
+		{name: "empty", items: nil, want: []int{}},
+		{name: "first page", items: []int{1, 2, 3, 4}, want: []int{1, 2, 3}, next: at(3, false, false)},
+		{name: "forward", items: []int{4, 5, 6, 7}, from: at(3, false, false), want: []int{4, 5, 6}, next: at(6, false, false), prev: at(4, false, true)},
+		{name: "forward last", items: []int{4, 5}, from: at(3, false, false), want: []int{4, 5}, prev: at(4, false, true)},
+		{name: "forward past end", items: nil, from: at(9, false, false), want: []int{}, prev: at(9, false, true)},
+		{name: "desc", items: []int{9, 8, 7, 6}, desc: true, want: []int{9, 8, 7}, next: at(7, true, false)},
+		// Backward pages come in display order with the extra row in front.
+		{name: "backward", items: []int{3, 4, 5, 6}, from: at(7, false, true), want: []int{4, 5, 6}, next: at(6, false, false), prev: at(4, false, true)},
+		{name: "backward first", items: []int{1, 2}, from: at(3, false, true), want: []int{1, 2}, next: at(2, false, false)},
+		{name: "backward past start", items: nil, from: at(1, false, true), want: []int{}, next: at(1, false, false)},
+		{name: "backward desc", items: []int{6, 5, 4, 3}, from: at(2, true, true), desc: true, want: []int{5, 4, 3}, next: at(3, true, false), prev: at(5, true, true)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := paginate(tt.items, 3, tt.from, "posts", tt.desc, position)
+			if !reflect.DeepEqual(page.Items, tt.want) {
+				t.Errorf("items = %v, want %v", page.Items, tt.want)
+			}
+			checkCursor(t, "next", page.NextCursor, tt.next)
+			checkCursor(t, "prev", page.PrevCursor, tt.prev)
+		})
+	}
+}
+
+func checkCursor(t *testing.T, name, token string, want *cursor) {
+	t.Helper()
+	if want == nil {
+		if token != "" {
+			t.Errorf("%s cursor = %q, want none", name, token)
+		}
+		return
+	}
+	got, err := decodeCursor(token, "posts", textKey)
+	if err != nil {
+		t.Fatalf("%s cursor %q: %v", name, token, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s cursor = %+v, want %+v", name, got, want)
+	}
+}
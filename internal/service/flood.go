@@ -0,0 +1,51 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/ratelimit"
+)
+
+// FloodControl bounds how fast a single author may write. The HTTP layer
+// additionally limits client IPs against the same limiter.
+type FloodControl struct {
+	Limiter ratelimit.Limiter
+	// DuplicateWindow rejects a post repeating a message its author posted
+	// to the same thread this recently; zero disables the check.
+	DuplicateWindow time.Duration
+}
+
+// allow charges a write of nickname against budget.
+func (f FloodControl) allow(budget ratelimit.Budget, nickname string) error {
+	if f.Limiter == nil {
+		return nil
+	}
+	if wait := f.Limiter.Allow(budget, "user:"+strings.ToLower(nickname)); wait > 0 {
+		return domain.RateLimited(wait, "Too many %s by %s, retry in %s", budget, nickname, wait.Round(time.Second))
+	}
+	return nil
+}
+
+// allowAll charges one write of each of nicknames against budget, repeated
+// ones once. Either every author is charged or, if one is out of writes,
+// none is.
+func (f FloodControl) allowAll(budget ratelimit.Budget, nicknames []string) error {
+	if f.Limiter == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(nicknames))
+	byKey := make(map[string]string, len(nicknames))
+	for _, nickname := range nicknames {
+		key := "user:" + strings.ToLower(nickname)
+		if _, ok := byKey[key]; !ok {
+			byKey[key] = nickname
+			keys = append(keys, key)
+		}
+	}
+	if key, wait := f.Limiter.AllowAll(budget, keys); wait > 0 {
+		return domain.RateLimited(wait, "Too many %s by %s, retry in %s", budget, byKey[key], wait.Round(time.Second))
+	}
+	return nil
+}
@@ -3,68 +3,89 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
 	"github.com/jackc/pgx/v5"
+	"github.com/rinatkh/db_forum/internal/auth"
 	"github.com/rinatkh/db_forum/internal/db"
+	"github.com/rinatkh/db_forum/internal/domain"
 	"github.com/rinatkh/db_forum/internal/model/core"
 	"github.com/rinatkh/db_forum/internal/model/dto"
+	"github.com/rinatkh/db_forum/internal/policy"
 	"github.com/sirupsen/logrus"
-	"net/http"
+	"time"
 )
 
 type ForumService interface {
-	CreateForum(ctx context.Context, request *dto.CreateForumRequest) (*dto.Response, error)
-	GetForum(ctx context.Context, request *dto.GetForumRequest) (*dto.Response, error)
-	GetForumThreads(ctx context.Context, request *dto.GetForumThreadsRequest) (*dto.Response, error)
-	GetForumUsers(ctx context.Context, request *dto.GetForumUsersRequest) (*dto.Response, error)
+	CreateForum(ctx context.Context, request *dto.CreateForumRequest) (*core.Forum, error)
+	GetForum(ctx context.Context, request *dto.GetForumRequest) (*core.Forum, error)
+	GetForums(ctx context.Context, slugs []string) ([]*core.Forum, error)
+	GetForumThreads(ctx context.Context, request *dto.GetForumThreadsRequest) ([]*core.Thread, error)
+	GetForumUsers(ctx context.Context, request *dto.GetForumUsersRequest) ([]*core.User, error)
+	GetForumThreadsPage(ctx context.Context, request *dto.GetForumThreadsRequest) (*dto.Page[*core.Thread], error)
+	GetForumUsersPage(ctx context.Context, request *dto.GetForumUsersRequest) (*dto.Page[*core.User], error)
+	DeleteForum(ctx context.Context, request *dto.DeleteForumRequest) (*core.Forum, error)
+	GetModerators(ctx context.Context, request *dto.GetModeratorsRequest) ([]*core.User, error)
+	AddModerator(ctx context.Context, request *dto.ModeratorRequest) ([]*core.User, error)
+	RemoveModerator(ctx context.Context, request *dto.ModeratorRequest) ([]*core.User, error)
 }
 
 type forumServiceImpl struct {
-	log *logrus.Entry
-	db  *db.Repository
+	log    *logrus.Entry
+	db     *db.Repository
+	policy policy.Policy
 }
 
-func (svc *forumServiceImpl) GetForum(ctx context.Context, request *dto.GetForumRequest) (*dto.Response, error) {
-	forum, err := svc.db.ForumRepository.GetForum(ctx, request.Slug)
+func (svc *forumServiceImpl) getForum(ctx context.Context, slug string) (*core.Forum, error) {
+	forum, err := svc.db.ForumRepository.GetForum(ctx, slug)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find forum with slug: %s", request.Slug)}, Code: http.StatusNotFound}, nil
+			return nil, domain.NotFound("forum", slug, "Can't find forum with slug: %s", slug)
 		}
+		return nil, err
 	}
-	return &dto.Response{Data: forum, Code: http.StatusOK}, nil
+	return forum, nil
 }
 
-func (svc *forumServiceImpl) GetForumThreads(ctx context.Context, request *dto.GetForumThreadsRequest) (*dto.Response, error) {
-	if forum, err := svc.db.ForumRepository.GetForum(ctx, request.Slug); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find forum with slug: %s", request.Slug)}, Code: http.StatusNotFound}, nil
-		}
-	} else {
-		request.Slug = forum.Slug
+func (svc *forumServiceImpl) GetForum(ctx context.Context, request *dto.GetForumRequest) (*core.Forum, error) {
+	return svc.getForum(ctx, request.Slug)
+}
+
+// GetForums looks up several forums at once; unknown slugs are left out.
+func (svc *forumServiceImpl) GetForums(ctx context.Context, slugs []string) ([]*core.Forum, error) {
+	if len(slugs) == 0 {
+		return nil, nil
 	}
+	return svc.db.ForumRepository.GetForumsBySlugs(ctx, slugs)
+}
 
-	threads, err := svc.db.ForumRepository.GetForumThreads(ctx, request.Slug, request.Limit, request.Since, request.Desc)
+func (svc *forumServiceImpl) GetForumThreads(ctx context.Context, request *dto.GetForumThreadsRequest) ([]*core.Thread, error) {
+	forum, err := svc.getForum(ctx, request.Slug)
 	if err != nil {
 		return nil, err
 	}
+	request.Slug = forum.Slug
 
-	return &dto.Response{Data: threads, Code: http.StatusOK}, nil
+	return svc.db.ForumRepository.GetForumThreads(ctx, request.Slug, request.Limit, request.Since, request.Desc)
 }
 
-func (svc *forumServiceImpl) CreateForum(ctx context.Context, request *dto.CreateForumRequest) (*dto.Response, error) {
+func (svc *forumServiceImpl) CreateForum(ctx context.Context, request *dto.CreateForumRequest) (*core.Forum, error) {
+	if err := auth.Authorize(ctx, request.User); err != nil {
+		return nil, err
+	}
+
 	if forum, err := svc.db.ForumRepository.GetForum(ctx, request.Slug); err != nil {
 		if !errors.Is(err, pgx.ErrNoRows) {
 			return nil, err
 		}
 	} else {
-		return &dto.Response{Data: forum, Code: http.StatusConflict}, nil
+		return nil, domain.Conflict("forum", request.Slug, forum, "Forum already exists: %s", forum.Slug)
 	}
 
 	user, err := svc.db.UserRepository.GetUserByNickname(ctx, request.User)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find user by nickname: %s", request.User)}, Code: http.StatusNotFound}, nil
+			return nil, domain.NotFound("user", request.User, "Can't find user by nickname: %s", request.User)
 		}
+		return nil, err
 	}
 	request.User = user.Nickname
 
@@ -72,31 +93,139 @@ func (svc *forumServiceImpl) CreateForum(ctx context.Context, request *dto.Creat
 		return nil, err
 	}
 
-	forum, err := svc.db.ForumRepository.GetForum(ctx, request.Slug)
+	return svc.db.ForumRepository.GetForum(ctx, request.Slug)
+}
+
+func (svc *forumServiceImpl) GetForumUsers(ctx context.Context, request *dto.GetForumUsersRequest) ([]*core.User, error) {
+	forum, err := svc.getForum(ctx, request.Slug)
 	if err != nil {
 		return nil, err
 	}
+	request.Slug = forum.Slug
 
-	return &dto.Response{Data: forum, Code: http.StatusCreated}, nil
+	return svc.db.ForumRepository.GetForumUsers(ctx, request.Slug, request.Limit, request.Since, request.Desc)
 }
 
-func (svc *forumServiceImpl) GetForumUsers(ctx context.Context, request *dto.GetForumUsersRequest) (*dto.Response, error) {
-	if forum, err := svc.db.ForumRepository.GetForum(ctx, request.Slug); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find forum with slug: %s", request.Slug)}, Code: http.StatusNotFound}, nil
-		}
-	} else {
-		request.Slug = forum.Slug
+// GetForumThreadsPage lists threads by creation time in cursor mode; since is
+// not used there, the cursor carries the position and order instead.
+func (svc *forumServiceImpl) GetForumThreadsPage(ctx context.Context, request *dto.GetForumThreadsRequest) (*dto.Page[*core.Thread], error) {
+	forum, err := svc.getForum(ctx, request.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	listing := "threads/" + forum.Slug
+	from, err := decodeCursor(request.Cursor, listing, timeKey)
+	if err != nil {
+		return nil, err
+	}
+	desc := request.Desc
+	if from != nil {
+		desc = from.Desc
+	}
+
+	threads, err := svc.db.ForumRepository.GetForumThreadsPage(ctx, forum.Slug, request.Limit+1, desc, from.keyset())
+	if err != nil {
+		return nil, err
+	}
+	return paginate(threads, request.Limit, from, listing, desc, func(t *core.Thread) (string, int64) {
+		return t.Created.Format(time.RFC3339Nano), t.ID
+	}), nil
+}
+
+func (svc *forumServiceImpl) GetForumUsersPage(ctx context.Context, request *dto.GetForumUsersRequest) (*dto.Page[*core.User], error) {
+	forum, err := svc.getForum(ctx, request.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	listing := "users/" + forum.Slug
+	from, err := decodeCursor(request.Cursor, listing, textKey)
+	if err != nil {
+		return nil, err
+	}
+	desc := request.Desc
+	if from != nil {
+		desc = from.Desc
+	}
+
+	users, err := svc.db.ForumRepository.GetForumUsersPage(ctx, forum.Slug, request.Limit+1, desc, from.keyset())
+	if err != nil {
+		return nil, err
+	}
+	return paginate(users, request.Limit, from, listing, desc, func(u *core.User) (string, int64) {
+		return u.Nickname, 0
+	}), nil
+}
+
+func (svc *forumServiceImpl) DeleteForum(ctx context.Context, request *dto.DeleteForumRequest) (*core.Forum, error) {
+	forum, err := svc.getForum(ctx, request.Slug)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.policy.Authorize(ctx, policy.DeleteForum, policy.Target{Forum: forum.Slug}); err != nil {
+		return nil, err
+	}
+
+	if err := svc.db.ForumRepository.DeleteForum(ctx, forum.Slug); err != nil {
+		return nil, err
+	}
+	return forum, nil
+}
+
+func (svc *forumServiceImpl) GetModerators(ctx context.Context, request *dto.GetModeratorsRequest) ([]*core.User, error) {
+	forum, err := svc.getForum(ctx, request.Slug)
+	if err != nil {
+		return nil, err
+	}
+	return svc.db.RoleRepository.GetModerators(ctx, forum.Slug)
+}
+
+// AddModerator grants the moderator role in a forum and returns its
+// moderators. Granting it twice is not an error.
+func (svc *forumServiceImpl) AddModerator(ctx context.Context, request *dto.ModeratorRequest) ([]*core.User, error) {
+	forum, err := svc.getForum(ctx, request.Slug)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.policy.Authorize(ctx, policy.ManageModerators, policy.Target{Forum: forum.Slug}); err != nil {
+		return nil, err
+	}
+
+	user, err := findActiveUser(ctx, svc.db.UserRepository, request.Nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	var grantedBy string
+	if identity, ok := auth.FromContext(ctx); ok {
+		grantedBy = identity.Nickname
+	}
+	if err := svc.db.RoleRepository.AddModerator(ctx, forum.Slug, user.Nickname, grantedBy); err != nil {
+		return nil, err
 	}
+	return svc.db.RoleRepository.GetModerators(ctx, forum.Slug)
+}
 
-	threads, err := svc.db.ForumRepository.GetForumUsers(ctx, request.Slug, request.Limit, request.Since, request.Desc)
+func (svc *forumServiceImpl) RemoveModerator(ctx context.Context, request *dto.ModeratorRequest) ([]*core.User, error) {
+	forum, err := svc.getForum(ctx, request.Slug)
 	if err != nil {
 		return nil, err
 	}
+	if err := svc.policy.Authorize(ctx, policy.ManageModerators, policy.Target{Forum: forum.Slug}); err != nil {
+		return nil, err
+	}
 
-	return &dto.Response{Data: threads, Code: http.StatusOK}, nil
+	removed, err := svc.db.RoleRepository.RemoveModerator(ctx, forum.Slug, request.Nickname)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return nil, domain.NotFound("moderator", request.Nickname, "User %s is not a moderator of forum %s", request.Nickname, forum.Slug)
+	}
+	return svc.db.RoleRepository.GetModerators(ctx, forum.Slug)
 }
 
-func NewForumService(log *logrus.Entry, db *db.Repository) ForumService {
-	return &forumServiceImpl{log: log, db: db}
+func NewForumService(log *logrus.Entry, db *db.Repository, policy policy.Policy) ForumService {
+	return &forumServiceImpl{log: log, db: db, policy: policy}
 }
@@ -3,144 +3,247 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
 	"github.com/jackc/pgx/v5"
+	"github.com/rinatkh/db_forum/internal/auth"
 	"github.com/rinatkh/db_forum/internal/db"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/events"
 	"github.com/rinatkh/db_forum/internal/model/core"
 	"github.com/rinatkh/db_forum/internal/model/dto"
+	"github.com/rinatkh/db_forum/internal/policy"
+	"github.com/rinatkh/db_forum/internal/ratelimit"
 	"github.com/sirupsen/logrus"
-	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type PostsService interface {
-	CreatePosts(ctx context.Context, soi string, posts []*dto.Post) (*dto.Response, error)
-	GetPosts(ctx context.Context, soi string, sort string, since int64, desc bool, limit int64) (*dto.Response, error)
-	GetPostDetails(ctx context.Context, request *dto.GetPostDetailsRequest) (*dto.Response, error)
-	EditPost(ctx context.Context, request *dto.EditPostRequest) (*dto.Response, error)
+	CreatePosts(ctx context.Context, soi string, posts []*dto.Post) ([]*core.Post, error)
+	GetPosts(ctx context.Context, soi string, sort string, since int64, desc bool, limit int64) ([]*core.Post, error)
+	GetPostsPage(ctx context.Context, request *dto.GetPostsRequest) (*dto.Page[*core.Post], error)
+	GetPostDetails(ctx context.Context, request *dto.GetPostDetailsRequest) (*dto.PostDetails, error)
+	EditPost(ctx context.Context, request *dto.EditPostRequest) (*core.Post, error)
+	GetPostsDepth(ctx context.Context, ids []int64) ([]*core.PostDepth, error)
 }
 
 type postsServiceImpl struct {
-	log *logrus.Entry
-	db  *db.Repository
+	log    *logrus.Entry
+	db     *db.Repository
+	policy policy.Policy
+	flood  FloodControl
+	events events.Publisher
 }
 
-func (svc *postsServiceImpl) EditPost(ctx context.Context, request *dto.EditPostRequest) (*dto.Response, error) {
-	post, err := svc.db.PostsRepository.GetPostByID(ctx, request.ID)
+func (svc *postsServiceImpl) getPost(ctx context.Context, id int64) (*core.Post, error) {
+	post, err := svc.db.PostsRepository.GetPostByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find post by id: %d", request.ID)}, Code: http.StatusNotFound}, nil
+			return nil, domain.NotFound("post", strconv.FormatInt(id, 10), "Can't find post by id: %d", id)
 		}
 		return nil, err
 	}
+	return post, nil
+}
 
-	if len(request.Message) == 0 || request.Message == post.Message {
-		return &dto.Response{Data: post, Code: http.StatusOK}, nil
+// GetPostsDepth looks up the nesting level of several posts at once; unknown
+// ids are left out.
+func (svc *postsServiceImpl) GetPostsDepth(ctx context.Context, ids []int64) ([]*core.PostDepth, error) {
+	if len(ids) == 0 {
+		return nil, nil
 	}
+	return svc.db.PostsRepository.GetPostsDepth(ctx, ids)
+}
 
-	updatedPost, err := svc.db.PostsRepository.EditPost(ctx, request.ID, request.Message)
+func (svc *postsServiceImpl) EditPost(ctx context.Context, request *dto.EditPostRequest) (*core.Post, error) {
+	post, err := svc.getPost(ctx, request.ID)
 	if err != nil {
 		return nil, err
 	}
+	if err := svc.policy.Authorize(ctx, policy.EditPost, policy.Target{Forum: post.Forum, Author: post.Author}); err != nil {
+		return nil, err
+	}
+
+	if len(request.Message) == 0 || request.Message == post.Message {
+		return post, nil
+	}
 
-	return &dto.Response{Data: updatedPost, Code: http.StatusOK}, nil
+	post, err = svc.db.PostsRepository.EditPost(ctx, request.ID, request.Message)
+	if err != nil {
+		return nil, err
+	}
+	publish(ctx, svc.events, events.Event{Type: events.PostEdited, Thread: post.Thread, Post: post})
+	return post, nil
 }
 
-func (svc *postsServiceImpl) CreatePosts(ctx context.Context, soi string, posts []*dto.Post) (*dto.Response, error) {
-	var id int
-	var err error
-	id, err = strconv.Atoi(soi)
+func (svc *postsServiceImpl) CreatePosts(ctx context.Context, soi string, posts []*dto.Post) ([]*core.Post, error) {
+	for _, post := range posts {
+		if err := auth.Authorize(ctx, post.Author); err != nil {
+			return nil, err
+		}
+	}
 
-	var thread *core.Thread
+	thread, err := findThread(ctx, svc.db.ThreadRepository, soi)
 	if err != nil {
-		if thread, err = svc.db.ThreadRepository.GetThreadBySlug(ctx, soi); err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by slug: %s", soi)}, Code: http.StatusNotFound}, nil
-			}
-		} else {
-			id = int(thread.ID)
-		}
-	} else {
-		if thread, err = svc.db.ThreadRepository.GetThreadByID(ctx, int64(id)); err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by id: %d", id)}, Code: http.StatusNotFound}, nil
-			}
-		}
+		return nil, err
 	}
 
 	if len(posts) == 0 {
-		return &dto.Response{Data: []struct{}{}, Code: http.StatusCreated}, nil
+		return []*core.Post{}, nil
+	}
+
+	if thread.Locked {
+		if err := svc.policy.Authorize(ctx, policy.PostToLockedThread, policy.Target{Forum: thread.Forum}); err != nil {
+			return nil, err
+		}
 	}
 
 	if posts[0].Parent != 0 {
 		parentThreadID, err := svc.db.PostsRepository.CheckParentPost(ctx, int(posts[0].Parent))
-		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				return &dto.Response{Data: dto.ErrorResponse{Message: "Parent post was created in another thread"}, Code: http.StatusConflict}, nil
-			}
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
 		}
-
-		if parentThreadID != id {
-			return &dto.Response{Data: dto.ErrorResponse{Message: "Parent post was created in another thread"}, Code: http.StatusConflict}, nil
+		if err != nil || int64(parentThreadID) != thread.ID {
+			return nil, domain.Conflict("post", strconv.FormatInt(posts[0].Parent, 10), nil, "Parent post was created in another thread")
 		}
 	}
 
 	if _, err := svc.db.UserRepository.GetUserByNickname(ctx, posts[0].Author); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find user by nickname: %s", posts[0].Author)}, Code: http.StatusNotFound}, nil
+			return nil, domain.NotFound("user", posts[0].Author, "Can't find user by nickname: %s", posts[0].Author)
 		}
+		return nil, err
 	}
 
-	insertedPosts, err := svc.db.PostsRepository.CreatePosts(ctx, thread.Forum, int64(id), posts)
-	if err != nil {
+	authors := make([]string, 0, len(posts))
+	for _, post := range posts {
+		authors = append(authors, post.Author)
+	}
+	if banned, err := svc.db.UserRepository.FindBannedUser(ctx, authors); err != nil {
 		return nil, err
+	} else if banned != "" {
+		return nil, domain.Forbidden("user", banned, "User is banned: %s", banned)
 	}
 
-	return &dto.Response{Data: insertedPosts, Code: http.StatusCreated}, nil
-}
+	if err := svc.flood.allowAll(ratelimit.Posts, authors); err != nil {
+		return nil, err
+	}
+	if err := svc.checkDuplicates(ctx, thread.ID, posts); err != nil {
+		return nil, err
+	}
 
-func (svc *postsServiceImpl) GetPosts(ctx context.Context, soi string, sort string, since int64, desc bool, limit int64) (*dto.Response, error) {
-	id, err := strconv.Atoi(soi)
+	created, err := svc.db.PostsRepository.CreatePosts(ctx, thread.Forum, thread.ID, posts)
 	if err != nil {
-		if thread, err := svc.db.ThreadRepository.GetThreadBySlug(ctx, soi); err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by slug: %s", soi)}, Code: http.StatusNotFound}, nil
-			}
-		} else {
-			id = int(thread.ID)
-		}
+		return nil, err
+	}
+	for _, post := range created {
+		publish(ctx, svc.events, events.Event{Type: events.PostCreated, Thread: thread.ID, Post: post})
+	}
+	return created, nil
+}
+
+// checkDuplicates rejects a batch repeating a message of the same author,
+// either within the batch or in the thread within the duplicate window.
+func (svc *postsServiceImpl) checkDuplicates(ctx context.Context, thread int64, posts []*dto.Post) error {
+	if svc.flood.DuplicateWindow <= 0 {
+		return nil
 	}
 
-	if _, err := svc.db.ThreadRepository.GetThreadByID(ctx, int64(id)); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by id: %d", id)}, Code: http.StatusNotFound}, nil
+	type authored struct{ author, message string }
+	seen := make(map[authored]bool, len(posts))
+	authors := make([]string, 0, len(posts))
+	messages := make([]string, 0, len(posts))
+	for _, post := range posts {
+		key := authored{strings.ToLower(post.Author), post.Message}
+		if seen[key] {
+			return domain.Conflict("post", post.Author, nil, "Duplicate message from %s", post.Author)
 		}
+		seen[key] = true
+		authors = append(authors, post.Author)
+		messages = append(messages, post.Message)
+	}
+
+	author, err := svc.db.PostsRepository.FindDuplicatePost(ctx, thread, authors, messages, time.Now().Add(-svc.flood.DuplicateWindow))
+	if err != nil {
+		return err
+	}
+	if author != "" {
+		return domain.Conflict("post", author, nil, "Duplicate message from %s", author)
+	}
+	return nil
+}
+
+func (svc *postsServiceImpl) GetPosts(ctx context.Context, soi string, sort string, since int64, desc bool, limit int64) ([]*core.Post, error) {
+	thread, err := findThread(ctx, svc.db.ThreadRepository, soi)
+	if err != nil {
+		return nil, err
 	}
+	id := int(thread.ID)
 
-	var posts []*core.Post
 	switch sort {
-	case "flat":
-		posts, err = svc.db.PostsRepository.GetPostsFlat(ctx, id, since, desc, limit)
 	case "tree":
-		posts, err = svc.db.PostsRepository.GetPostsTree(ctx, id, since, desc, limit)
+		return svc.db.PostsRepository.GetPostsTree(ctx, id, since, desc, limit)
 	case "parent_tree":
-		posts, err = svc.db.PostsRepository.GetPostsParentTree(ctx, id, since, desc, limit)
+		return svc.db.PostsRepository.GetPostsParentTree(ctx, id, since, desc, limit)
 	default:
-		posts, err = svc.db.PostsRepository.GetPostsFlat(ctx, id, since, desc, limit)
+		return svc.db.PostsRepository.GetPostsFlat(ctx, id, since, desc, limit)
 	}
+}
+
+// GetPostsPage lists posts in cursor mode. Parent tree pages hold limit root
+// posts with all of their replies, so they are paginated by root.
+func (svc *postsServiceImpl) GetPostsPage(ctx context.Context, request *dto.GetPostsRequest) (*dto.Page[*core.Post], error) {
+	thread, err := findThread(ctx, svc.db.ThreadRepository, request.SlugOrID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &dto.Response{Data: posts, Code: http.StatusOK}, nil
-}
+	listing := "posts/" + strconv.FormatInt(thread.ID, 10) + "/" + request.Sort
+	format := timeKey
+	if request.Sort == "parent_tree" {
+		format = textKey
+	}
+	from, err := decodeCursor(request.Cursor, listing, format)
+	if err != nil {
+		return nil, err
+	}
+	desc := request.Desc
+	if from != nil {
+		desc = from.Desc
+	}
 
-func (svc *postsServiceImpl) GetPostDetails(ctx context.Context, request *dto.GetPostDetailsRequest) (*dto.Response, error) {
-	post, err := svc.db.PostsRepository.GetPostByID(ctx, request.ID)
+	posts, err := svc.db.PostsRepository.GetPostsPage(ctx, int(thread.ID), request.Sort, request.Limit+1, desc, from.keyset())
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find post by id: %d", request.ID)}, Code: http.StatusNotFound}, nil
+		return nil, err
+	}
+
+	if request.Sort != "parent_tree" {
+		return paginate(posts, request.Limit, from, listing, desc, func(p *core.Post) (string, int64) {
+			return p.Created.Format(time.RFC3339Nano), p.ID
+		}), nil
+	}
+
+	var trees [][]*core.Post
+	for _, post := range posts {
+		if post.Parent == 0 || len(trees) == 0 {
+			trees = append(trees, nil)
 		}
+		trees[len(trees)-1] = append(trees[len(trees)-1], post)
+	}
+	treesPage := paginate(trees, request.Limit, from, listing, desc, func(tree []*core.Post) (string, int64) {
+		return "", tree[0].ID
+	})
+
+	page := &dto.Page[*core.Post]{Items: []*core.Post{}, NextCursor: treesPage.NextCursor, PrevCursor: treesPage.PrevCursor}
+	for _, tree := range treesPage.Items {
+		page.Items = append(page.Items, tree...)
+	}
+	return page, nil
+}
+
+func (svc *postsServiceImpl) GetPostDetails(ctx context.Context, request *dto.GetPostDetailsRequest) (*dto.PostDetails, error) {
+	post, err := svc.getPost(ctx, request.ID)
+	if err != nil {
 		return nil, err
 	}
 
@@ -150,9 +253,9 @@ func (svc *postsServiceImpl) GetPostDetails(ctx context.Context, request *dto.Ge
 	}
 	postDetails.Post = post
 
-	return &dto.Response{Data: postDetails, Code: http.StatusOK}, nil
+	return &postDetails, nil
 }
 
-func NewPostsService(log *logrus.Entry, db *db.Repository) PostsService {
-	return &postsServiceImpl{log: log, db: db}
+func NewPostsService(log *logrus.Entry, db *db.Repository, policy policy.Policy, flood FloodControl, publisher events.Publisher) PostsService {
+	return &postsServiceImpl{log: log, db: db, policy: policy, flood: flood, events: publisher}
 }
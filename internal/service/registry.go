@@ -1,7 +1,12 @@
 package service
 
 import (
+	"context"
+
+	"github.com/rinatkh/db_forum/internal/auth"
 	"github.com/rinatkh/db_forum/internal/db"
+	"github.com/rinatkh/db_forum/internal/events"
+	"github.com/rinatkh/db_forum/internal/policy"
 	"github.com/sirupsen/logrus"
 )
 
@@ -10,14 +15,26 @@ type Registry struct {
 	ForumService  ForumService
 	ThreadService ThreadService
 	PostsService  PostsService
+	AuthService   AuthService
+	// Policy is shared by the services and exposed for handlers that act on
+	// repositories directly.
+	Policy policy.Policy
 }
 
-func NewRegistry(log *logrus.Entry, repository *db.Repository) *Registry {
+func NewRegistry(log *logrus.Entry, repository *db.Repository, signer *auth.Signer, flood FloodControl, publisher events.Publisher) *Registry {
 	registry := new(Registry)
 
-	registry.UserService = NewUserService(log, repository)
-	registry.ForumService = NewForumService(log, repository)
-	registry.ThreadService = NewThreadService(log, repository)
-	registry.PostsService = NewPostsService(log, repository)
+	registry.Policy = policy.NewPolicy(log, repository.RoleRepository)
+	registry.UserService = NewUserService(log, repository, registry.Policy)
+	registry.ForumService = NewForumService(log, repository, registry.Policy)
+	registry.ThreadService = NewThreadService(log, repository, registry.Policy, flood, publisher)
+	registry.PostsService = NewPostsService(log, repository, registry.Policy, flood, publisher)
+	registry.AuthService = NewAuthService(log, repository, signer)
 	return registry
 }
+
+// publish hands event to publisher once the changes it describes are
+// committed.
+func publish(ctx context.Context, publisher events.Publisher, event events.Event) {
+	db.AfterCommit(ctx, func() { publisher.Publish(event) })
+}
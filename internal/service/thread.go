@@ -3,67 +3,110 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
 	"github.com/jackc/pgx/v5"
+	"github.com/rinatkh/db_forum/internal/auth"
 	"github.com/rinatkh/db_forum/internal/db"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/events"
 	"github.com/rinatkh/db_forum/internal/model/core"
 	"github.com/rinatkh/db_forum/internal/model/dto"
+	"github.com/rinatkh/db_forum/internal/policy"
+	"github.com/rinatkh/db_forum/internal/ratelimit"
 	"github.com/sirupsen/logrus"
-	"net/http"
 	"strconv"
 )
 
 type ThreadService interface {
-	CreateThread(ctx context.Context, request *dto.CreateThreadRequest) (*dto.Response, error)
-	CountVote(ctx context.Context, soi string, request *dto.EditVoteRequest) (*dto.Response, error)
-	GetThread(ctx context.Context, soi string) (*dto.Response, error)
-	EditThread(ctx context.Context, soi string, request *dto.EditThreadRequest) (*dto.Response, error)
+	CreateThread(ctx context.Context, request *dto.CreateThreadRequest) (*core.Thread, error)
+	CountVote(ctx context.Context, soi string, request *dto.EditVoteRequest) (*core.Thread, error)
+	GetThread(ctx context.Context, soi string) (*core.Thread, error)
+	EditThread(ctx context.Context, soi string, request *dto.EditThreadRequest) (*core.Thread, error)
+	LockThread(ctx context.Context, soi string, request *dto.LockThreadRequest) (*core.Thread, error)
+	GetThreadsActivity(ctx context.Context, ids []int64) ([]*core.ThreadActivity, error)
 }
 
 type threadServiceImpl struct {
-	log *logrus.Entry
-	db  *db.Repository
+	log    *logrus.Entry
+	db     *db.Repository
+	policy policy.Policy
+	flood  FloodControl
+	events events.Publisher
 }
 
-func (svc *threadServiceImpl) GetThread(ctx context.Context, soi string) (*dto.Response, error) {
+// findThread resolves the slug_or_id path parameter shared by the thread endpoints.
+func findThread(ctx context.Context, repo db.ThreadRepository, soi string) (*core.Thread, error) {
 	id, err := strconv.Atoi(soi)
 	if err != nil {
-		if thread, err := svc.db.ThreadRepository.GetThreadBySlug(ctx, soi); err != nil {
+		thread, err := repo.GetThreadBySlug(ctx, soi)
+		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
-				return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by slug: %s", soi)}, Code: http.StatusNotFound}, nil
+				return nil, domain.NotFound("thread", soi, "Can't find thread forum by slug: %s", soi)
 			}
 			return nil, err
-		} else {
-			return &dto.Response{Data: thread, Code: http.StatusOK}, nil
 		}
+		return thread, nil
 	}
 
-	thread, err := svc.db.ThreadRepository.GetThreadByID(ctx, int64(id))
+	thread, err := repo.GetThreadByID(ctx, int64(id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by id: %d", id)}, Code: http.StatusNotFound}, nil
+			return nil, domain.NotFound("thread", soi, "Can't find thread forum by id: %d", id)
 		}
+		return nil, err
 	}
-
-	return &dto.Response{Data: thread, Code: http.StatusOK}, nil
+	return thread, nil
 }
 
-func (svc *threadServiceImpl) CreateThread(ctx context.Context, request *dto.CreateThreadRequest) (*dto.Response, error) {
-	user, err := svc.db.UserRepository.GetUserByNickname(ctx, request.Author)
+// findActiveUser loads a user that is allowed to write, i.e. exists and is not banned.
+func findActiveUser(ctx context.Context, repo db.UserRepository, nickname string) (*core.User, error) {
+	user, err := repo.GetUserByNickname(ctx, nickname)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find user by nickname: %s", request.Author)}, Code: http.StatusNotFound}, nil
+			return nil, domain.NotFound("user", nickname, "Can't find user by nickname: %s", nickname)
 		}
+		return nil, err
+	}
+	if user.Banned {
+		return nil, domain.Forbidden("user", user.Nickname, "User is banned: %s", user.Nickname)
+	}
+	return user, nil
+}
+
+// GetThreadsActivity looks up the activity of several threads at once;
+// unknown ids are left out.
+func (svc *threadServiceImpl) GetThreadsActivity(ctx context.Context, ids []int64) ([]*core.ThreadActivity, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return svc.db.ThreadRepository.GetThreadsActivity(ctx, ids)
+}
+
+func (svc *threadServiceImpl) GetThread(ctx context.Context, soi string) (*core.Thread, error) {
+	return findThread(ctx, svc.db.ThreadRepository, soi)
+}
+
+func (svc *threadServiceImpl) CreateThread(ctx context.Context, request *dto.CreateThreadRequest) (*core.Thread, error) {
+	if err := auth.Authorize(ctx, request.Author); err != nil {
+		return nil, err
+	}
+
+	user, err := findActiveUser(ctx, svc.db.UserRepository, request.Author)
+	if err != nil {
+		return nil, err
 	}
 	request.Author = user.Nickname
+	if err := svc.flood.allow(ratelimit.Threads, user.Nickname); err != nil {
+		return nil, err
+	}
 
-	if forum, err := svc.db.ForumRepository.GetForum(ctx, request.Forum); err != nil {
+	forum, err := svc.db.ForumRepository.GetForum(ctx, request.Forum)
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by slug: %s", request.Forum)}, Code: http.StatusNotFound}, nil
+			return nil, domain.NotFound("forum", request.Forum, "Can't find thread forum by slug: %s", request.Forum)
 		}
-	} else {
-		request.Forum = forum.Slug
+		return nil, err
 	}
+	request.Forum = forum.Slug
 
 	if request.Slug != "" {
 		if thread, err := svc.db.ThreadRepository.GetThreadBySlug(ctx, request.Slug); err != nil {
@@ -71,48 +114,32 @@ func (svc *threadServiceImpl) CreateThread(ctx context.Context, request *dto.Cre
 				return nil, err
 			}
 		} else {
-			return &dto.Response{Data: thread, Code: http.StatusConflict}, nil
+			return nil, domain.Conflict("thread", request.Slug, thread, "Thread already exists: %s", thread.Slug)
 		}
 	}
 
 	reqThread := &core.Thread{Forum: request.Forum, Title: request.Title, Author: request.Author, Message: request.Message, Slug: request.Slug, Created: request.Created}
-	thread, err := svc.db.ThreadRepository.CreateThread(ctx, reqThread)
-	if err != nil {
-		return nil, err
-	}
-
-	return &dto.Response{Data: thread, Code: http.StatusCreated}, nil
+	return svc.db.ThreadRepository.CreateThread(ctx, reqThread)
 }
 
-func (svc *threadServiceImpl) CountVote(ctx context.Context, soi string, request *dto.EditVoteRequest) (*dto.Response, error) {
-	var id int
-	var err error
-	id, err = strconv.Atoi(soi)
+func (svc *threadServiceImpl) CountVote(ctx context.Context, soi string, request *dto.EditVoteRequest) (*core.Thread, error) {
+	if err := auth.Authorize(ctx, request.Nickname); err != nil {
+		return nil, err
+	}
 
-	var thread *core.Thread
+	thread, err := findThread(ctx, svc.db.ThreadRepository, soi)
 	if err != nil {
-		if thread, err = svc.db.ThreadRepository.GetThreadBySlug(ctx, soi); err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by slug: %s", soi)}, Code: http.StatusNotFound}, nil
-			}
-		} else {
-			id = int(thread.ID)
-		}
-	} else {
-		if thread, err = svc.db.ThreadRepository.GetThreadByID(ctx, int64(id)); err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by id: %d", id)}, Code: http.StatusNotFound}, nil
-			}
-		}
+		return nil, err
 	}
 
-	user, err := svc.db.UserRepository.GetUserByNickname(ctx, request.Nickname)
+	user, err := findActiveUser(ctx, svc.db.UserRepository, request.Nickname)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find user by nickname: %s", request.Nickname)}, Code: http.StatusNotFound}, nil
-		}
+		return nil, err
 	}
 	request.Nickname = user.Nickname
+	if err := svc.flood.allow(ratelimit.Votes, user.Nickname); err != nil {
+		return nil, err
+	}
 
 	exists, err := svc.db.VotesRepository.VoteExists(ctx, request.Nickname, thread.ID)
 	if err != nil {
@@ -122,9 +149,10 @@ func (svc *threadServiceImpl) CountVote(ctx context.Context, soi string, request
 	if exists {
 		if ok, err := svc.db.VotesRepository.EditVote(ctx, thread.ID, request.Nickname, request.Voice); err != nil {
 			return nil, err
-		} else if ok {
-			thread.Votes += request.Voice * 2
+		} else if !ok {
+			return thread, nil
 		}
+		thread.Votes += request.Voice * 2
 	} else {
 		newVote := &core.Vote{
 			Nickname: request.Nickname,
@@ -139,29 +167,18 @@ func (svc *threadServiceImpl) CountVote(ctx context.Context, soi string, request
 		thread.Votes += request.Voice
 	}
 
-	return &dto.Response{Data: thread, Code: http.StatusOK}, nil
+	votes := thread.Votes
+	publish(ctx, svc.events, events.Event{Type: events.VotesChanged, Thread: thread.ID, Votes: &votes})
+	return thread, nil
 }
 
-func (svc *threadServiceImpl) EditThread(ctx context.Context, soi string, request *dto.EditThreadRequest) (*dto.Response, error) {
-	var thread *core.Thread
-	var err error
-
-	id, err := strconv.Atoi(soi)
+func (svc *threadServiceImpl) EditThread(ctx context.Context, soi string, request *dto.EditThreadRequest) (*core.Thread, error) {
+	thread, err := findThread(ctx, svc.db.ThreadRepository, soi)
 	if err != nil {
-		if thread, err = svc.db.ThreadRepository.GetThreadBySlug(ctx, soi); err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by slug: %s", soi)}, Code: http.StatusNotFound}, nil
-			}
-			return nil, err
-		} else {
-			id = int(thread.ID)
-		}
+		return nil, err
 	}
-
-	if thread, err = svc.db.ThreadRepository.GetThreadByID(ctx, int64(id)); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find thread forum by id: %d", id)}, Code: http.StatusNotFound}, nil
-		}
+	if err := svc.policy.Authorize(ctx, policy.EditThread, policy.Target{Forum: thread.Forum, Author: thread.Author}); err != nil {
+		return nil, err
 	}
 
 	if len(request.Title) == 0 {
@@ -172,10 +189,24 @@ func (svc *threadServiceImpl) EditThread(ctx context.Context, soi string, reques
 		request.Message = thread.Message
 	}
 
-	thread, err = svc.db.ThreadRepository.UpdateThreadByID(ctx, int64(id), request.Title, request.Message)
-	return &dto.Response{Data: thread, Code: http.StatusOK}, err
+	return svc.db.ThreadRepository.UpdateThreadByID(ctx, thread.ID, request.Title, request.Message)
+}
+
+func (svc *threadServiceImpl) LockThread(ctx context.Context, soi string, request *dto.LockThreadRequest) (*core.Thread, error) {
+	thread, err := findThread(ctx, svc.db.ThreadRepository, soi)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.policy.Authorize(ctx, policy.LockThread, policy.Target{Forum: thread.Forum, Author: thread.Author}); err != nil {
+		return nil, err
+	}
+
+	if thread.Locked == request.Locked {
+		return thread, nil
+	}
+	return svc.db.ThreadRepository.SetThreadLocked(ctx, thread.ID, request.Locked)
 }
 
-func NewThreadService(log *logrus.Entry, db *db.Repository) ThreadService {
-	return &threadServiceImpl{log: log, db: db}
+func NewThreadService(log *logrus.Entry, db *db.Repository, policy policy.Policy, flood FloodControl, publisher events.Publisher) ThreadService {
+	return &threadServiceImpl{log: log, db: db, policy: policy, flood: flood, events: publisher}
 }
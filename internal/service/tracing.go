@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"github.com/rinatkh/db_forum/internal/domain"
+	"github.com/rinatkh/db_forum/internal/model/core"
+	"github.com/rinatkh/db_forum/internal/model/dto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/rinatkh/db_forum/internal/service"
+
+// WithTracing returns a copy of registry whose service calls are wrapped into spans.
+func WithTracing(registry *Registry) *Registry {
+	tracer := otel.Tracer(tracerName)
+	return &Registry{
+		UserService:   &userServiceTracing{next: registry.UserService, tracer: tracer},
+		ForumService:  &forumServiceTracing{next: registry.ForumService, tracer: tracer},
+		ThreadService: &threadServiceTracing{next: registry.ThreadService, tracer: tracer},
+		PostsService:  &postsServiceTracing{next: registry.PostsService, tracer: tracer},
+		AuthService:   &authServiceTracing{next: registry.AuthService, tracer: tracer},
+		Policy:        registry.Policy,
+	}
+}
+
+// endSpan marks the span as failed for unexpected errors only; domain errors
+// are part of normal operation and are recorded as an attribute.
+func endSpan(span trace.Span, err error) {
+	if domainErr, ok := domain.As(err); ok {
+		span.SetAttributes(attribute.String("domain.error", domainErr.Kind.String()))
+	} else if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+type userServiceTracing struct {
+	next   UserService
+	tracer trace.Tracer
+}
+
+func (s *userServiceTracing) CreateUser(ctx context.Context, request *dto.CreateUserRequest) (*core.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.CreateUser")
+	res, err := s.next.CreateUser(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *userServiceTracing) GetUserProfile(ctx context.Context, request *dto.GetUserProfileRequest) (*core.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.GetUserProfile")
+	res, err := s.next.GetUserProfile(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *userServiceTracing) GetUsers(ctx context.Context, nicknames []string) ([]*core.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.GetUsers")
+	res, err := s.next.GetUsers(ctx, nicknames)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *userServiceTracing) EditUserProfile(ctx context.Context, request *dto.EditUserProfileRequest) (*core.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.EditUserProfile")
+	res, err := s.next.EditUserProfile(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *userServiceTracing) BanUser(ctx context.Context, request *dto.BanUserRequest) (*core.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.BanUser")
+	res, err := s.next.BanUser(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *userServiceTracing) SetUserAdmin(ctx context.Context, request *dto.SetUserAdminRequest) (*core.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.SetUserAdmin")
+	res, err := s.next.SetUserAdmin(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+type forumServiceTracing struct {
+	next   ForumService
+	tracer trace.Tracer
+}
+
+func (s *forumServiceTracing) CreateForum(ctx context.Context, request *dto.CreateForumRequest) (*core.Forum, error) {
+	ctx, span := s.tracer.Start(ctx, "ForumService.CreateForum")
+	res, err := s.next.CreateForum(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *forumServiceTracing) GetForum(ctx context.Context, request *dto.GetForumRequest) (*core.Forum, error) {
+	ctx, span := s.tracer.Start(ctx, "ForumService.GetForum")
+	res, err := s.next.GetForum(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *forumServiceTracing) GetForums(ctx context.Context, slugs []string) ([]*core.Forum, error) {
+	ctx, span := s.tracer.Start(ctx, "ForumService.GetForums")
+	res, err := s.next.GetForums(ctx, slugs)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *forumServiceTracing) GetForumThreads(ctx context.Context, request *dto.GetForumThreadsRequest) ([]*core.Thread, error) {
+	ctx, span := s.tracer.Start(ctx, "ForumService.GetForumThreads")
+	res, err := s.next.GetForumThreads(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *forumServiceTracing) GetForumUsers(ctx context.Context, request *dto.GetForumUsersRequest) ([]*core.User, error) {
+	ctx, span := s.tracer.Start(ctx, "ForumService.GetForumUsers")
+	res, err := s.next.GetForumUsers(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *forumServiceTracing) GetForumThreadsPage(ctx context.Context, request *dto.GetForumThreadsRequest) (*dto.Page[*core.Thread], error) {
+	ctx, span := s.tracer.Start(ctx, "ForumService.GetForumThreadsPage")
+	res, err := s.next.GetForumThreadsPage(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *forumServiceTracing) GetForumUsersPage(ctx context.Context, request *dto.GetForumUsersRequest) (*dto.Page[*core.User], error) {
+	ctx, span := s.tracer.Start(ctx, "ForumService.GetForumUsersPage")
+	res, err := s.next.GetForumUsersPage(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *forumServiceTracing) DeleteForum(ctx context.Context, request *dto.DeleteForumRequest) (*core.Forum, error) {
+	ctx, span := s.tracer.Start(ctx, "ForumService.DeleteForum")
+	res, err := s.next.DeleteForum(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *forumServiceTracing) GetModerators(ctx context.Context, request *dto.GetModeratorsRequest) ([]*core.User, error) {
+	ctx, span := s.tracer.Start(ctx, "ForumService.GetModerators")
+	res, err := s.next.GetModerators(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *forumServiceTracing) AddModerator(ctx context.Context, request *dto.ModeratorRequest) ([]*core.User, error) {
+	ctx, span := s.tracer.Start(ctx, "ForumService.AddModerator")
+	res, err := s.next.AddModerator(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *forumServiceTracing) RemoveModerator(ctx context.Context, request *dto.ModeratorRequest) ([]*core.User, error) {
+	ctx, span := s.tracer.Start(ctx, "ForumService.RemoveModerator")
+	res, err := s.next.RemoveModerator(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+type threadServiceTracing struct {
+	next   ThreadService
+	tracer trace.Tracer
+}
+
+func (s *threadServiceTracing) CreateThread(ctx context.Context, request *dto.CreateThreadRequest) (*core.Thread, error) {
+	ctx, span := s.tracer.Start(ctx, "ThreadService.CreateThread")
+	res, err := s.next.CreateThread(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *threadServiceTracing) CountVote(ctx context.Context, soi string, request *dto.EditVoteRequest) (*core.Thread, error) {
+	ctx, span := s.tracer.Start(ctx, "ThreadService.CountVote")
+	res, err := s.next.CountVote(ctx, soi, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *threadServiceTracing) GetThread(ctx context.Context, soi string) (*core.Thread, error) {
+	ctx, span := s.tracer.Start(ctx, "ThreadService.GetThread")
+	res, err := s.next.GetThread(ctx, soi)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *threadServiceTracing) EditThread(ctx context.Context, soi string, request *dto.EditThreadRequest) (*core.Thread, error) {
+	ctx, span := s.tracer.Start(ctx, "ThreadService.EditThread")
+	res, err := s.next.EditThread(ctx, soi, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *threadServiceTracing) LockThread(ctx context.Context, soi string, request *dto.LockThreadRequest) (*core.Thread, error) {
+	ctx, span := s.tracer.Start(ctx, "ThreadService.LockThread")
+	res, err := s.next.LockThread(ctx, soi, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *threadServiceTracing) GetThreadsActivity(ctx context.Context, ids []int64) ([]*core.ThreadActivity, error) {
+	ctx, span := s.tracer.Start(ctx, "ThreadService.GetThreadsActivity")
+	res, err := s.next.GetThreadsActivity(ctx, ids)
+	endSpan(span, err)
+	return res, err
+}
+
+type postsServiceTracing struct {
+	next   PostsService
+	tracer trace.Tracer
+}
+
+func (s *postsServiceTracing) CreatePosts(ctx context.Context, soi string, posts []*dto.Post) ([]*core.Post, error) {
+	ctx, span := s.tracer.Start(ctx, "PostsService.CreatePosts")
+	res, err := s.next.CreatePosts(ctx, soi, posts)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *postsServiceTracing) GetPosts(ctx context.Context, soi string, sort string, since int64, desc bool, limit int64) ([]*core.Post, error) {
+	ctx, span := s.tracer.Start(ctx, "PostsService.GetPosts")
+	res, err := s.next.GetPosts(ctx, soi, sort, since, desc, limit)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *postsServiceTracing) GetPostsPage(ctx context.Context, request *dto.GetPostsRequest) (*dto.Page[*core.Post], error) {
+	ctx, span := s.tracer.Start(ctx, "PostsService.GetPostsPage")
+	res, err := s.next.GetPostsPage(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *postsServiceTracing) GetPostDetails(ctx context.Context, request *dto.GetPostDetailsRequest) (*dto.PostDetails, error) {
+	ctx, span := s.tracer.Start(ctx, "PostsService.GetPostDetails")
+	res, err := s.next.GetPostDetails(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *postsServiceTracing) EditPost(ctx context.Context, request *dto.EditPostRequest) (*core.Post, error) {
+	ctx, span := s.tracer.Start(ctx, "PostsService.EditPost")
+	res, err := s.next.EditPost(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *postsServiceTracing) GetPostsDepth(ctx context.Context, ids []int64) ([]*core.PostDepth, error) {
+	ctx, span := s.tracer.Start(ctx, "PostsService.GetPostsDepth")
+	res, err := s.next.GetPostsDepth(ctx, ids)
+	endSpan(span, err)
+	return res, err
+}
+
+type authServiceTracing struct {
+	next   AuthService
+	tracer trace.Tracer
+}
+
+func (s *authServiceTracing) Login(ctx context.Context, request *dto.LoginRequest) (*dto.LoginResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "AuthService.Login")
+	res, err := s.next.Login(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *authServiceTracing) SetPassword(ctx context.Context, request *dto.SetPasswordRequest) (*dto.LoginResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "AuthService.SetPassword")
+	res, err := s.next.SetPassword(ctx, request)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *authServiceTracing) CreateAPIToken(ctx context.Context, nickname string, name string) (string, error) {
+	ctx, span := s.tracer.Start(ctx, "AuthService.CreateAPIToken")
+	res, err := s.next.CreateAPIToken(ctx, nickname, name)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s *authServiceTracing) Authenticate(ctx context.Context, token string) (string, error) {
+	ctx, span := s.tracer.Start(ctx, "AuthService.Authenticate")
+	res, err := s.next.Authenticate(ctx, token)
+	endSpan(span, err)
+	return res, err
+}
@@ -3,71 +3,130 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
 	"github.com/jackc/pgx/v5"
+	"github.com/rinatkh/db_forum/internal/auth"
 	"github.com/rinatkh/db_forum/internal/db"
+	"github.com/rinatkh/db_forum/internal/domain"
 	"github.com/rinatkh/db_forum/internal/model/core"
 	"github.com/rinatkh/db_forum/internal/model/dto"
-	"net/http"
-
+	"github.com/rinatkh/db_forum/internal/policy"
 	"github.com/sirupsen/logrus"
 )
 
 type UserService interface {
-	CreateUser(ctx context.Context, request *dto.CreateUserRequest) (*dto.Response, error)
-	GetUserProfile(ctx context.Context, request *dto.GetUserProfileRequest) (*dto.Response, error)
-	EditUserProfile(ctx context.Context, request *dto.EditUserProfileRequest) (*dto.Response, error)
+	CreateUser(ctx context.Context, request *dto.CreateUserRequest) (*core.User, error)
+	GetUserProfile(ctx context.Context, request *dto.GetUserProfileRequest) (*core.User, error)
+	GetUsers(ctx context.Context, nicknames []string) ([]*core.User, error)
+	EditUserProfile(ctx context.Context, request *dto.EditUserProfileRequest) (*core.User, error)
+	BanUser(ctx context.Context, request *dto.BanUserRequest) (*core.User, error)
+	SetUserAdmin(ctx context.Context, request *dto.SetUserAdminRequest) (*core.User, error)
 }
 
 type userServiceImpl struct {
-	log *logrus.Entry
-	db  *db.Repository
+	log    *logrus.Entry
+	db     *db.Repository
+	policy policy.Policy
 }
 
-func (svc *userServiceImpl) EditUserProfile(ctx context.Context, request *dto.EditUserProfileRequest) (*dto.Response, error) {
+func (svc *userServiceImpl) EditUserProfile(ctx context.Context, request *dto.EditUserProfileRequest) (*core.User, error) {
+	if err := auth.Authorize(ctx, request.Nickname); err != nil {
+		return nil, err
+	}
+
 	if len(request.Email) > 0 {
 		if user, err := svc.db.UserRepository.GetUserByEmail(ctx, request.Email); err != nil {
 			if !errors.Is(err, pgx.ErrNoRows) {
 				return nil, err
 			}
 		} else if user.Nickname != request.Nickname {
-			return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("This email is already registered by user: %s", user.Nickname)}, Code: http.StatusConflict}, nil
+			return nil, domain.Conflict("user", request.Email, nil, "This email is already registered by user: %s", user.Nickname)
 		}
 	}
 
 	user := &core.User{Nickname: request.Nickname, Fullname: request.Fullname, About: request.About, Email: request.Email}
 	updatedUser, err := svc.db.UserRepository.EditUser(ctx, user)
 	if err != nil {
-		return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find user by nickname: %s", request.Nickname)}, Code: http.StatusNotFound}, nil
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("user", request.Nickname, "Can't find user by nickname: %s", request.Nickname)
+		}
+		return nil, err
 	}
-	return &dto.Response{Data: updatedUser, Code: http.StatusOK}, nil
+	return updatedUser, nil
 }
 
-func (svc *userServiceImpl) CreateUser(ctx context.Context, request *dto.CreateUserRequest) (*dto.Response, error) {
+func (svc *userServiceImpl) CreateUser(ctx context.Context, request *dto.CreateUserRequest) (*core.User, error) {
 	if users, err := svc.db.UserRepository.GetUsersByEmailOrNickname(ctx, request.Email, request.Nickname); err != nil {
 		return nil, err
 	} else if len(users) > 0 {
-		return &dto.Response{Data: users, Code: http.StatusConflict}, nil
+		return nil, domain.Conflict("user", request.Nickname, users, "User already exists: %s", request.Nickname)
 	}
 
 	user := &core.User{Nickname: request.Nickname, Fullname: request.Fullname, About: request.About, Email: request.Email}
 	if err := svc.db.UserRepository.CreateUser(ctx, user); err != nil {
 		return nil, err
 	}
+	if request.Password != "" {
+		hash, err := auth.HashPassword(request.Password)
+		if err != nil {
+			return nil, err
+		}
+		if err := svc.db.AuthRepository.SetPasswordHash(ctx, user.Nickname, hash); err != nil {
+			return nil, err
+		}
+	}
 
-	return &dto.Response{Data: user, Code: http.StatusCreated}, nil
+	return user, nil
 }
 
-func (svc *userServiceImpl) GetUserProfile(ctx context.Context, request *dto.GetUserProfileRequest) (*dto.Response, error) {
+func (svc *userServiceImpl) GetUserProfile(ctx context.Context, request *dto.GetUserProfileRequest) (*core.User, error) {
 	user, err := svc.db.UserRepository.GetUserByNickname(ctx, request.Nickname)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return &dto.Response{Data: dto.ErrorResponse{Message: fmt.Sprintf("Can't find user by nickname: %s", request.Nickname)}, Code: http.StatusNotFound}, nil
+			return nil, domain.NotFound("user", request.Nickname, "Can't find user by nickname: %s", request.Nickname)
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUsers looks up several users at once; unknown nicknames are left out.
+func (svc *userServiceImpl) GetUsers(ctx context.Context, nicknames []string) ([]*core.User, error) {
+	if len(nicknames) == 0 {
+		return nil, nil
+	}
+	return svc.db.UserRepository.GetUsersByNicknames(ctx, nicknames)
+}
+
+func (svc *userServiceImpl) BanUser(ctx context.Context, request *dto.BanUserRequest) (*core.User, error) {
+	if err := svc.policy.Authorize(ctx, policy.ManageUsers, policy.Target{}); err != nil {
+		return nil, err
+	}
+
+	user, err := svc.db.UserRepository.SetUserBanned(ctx, request.Nickname, request.Banned)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("user", request.Nickname, "Can't find user by nickname: %s", request.Nickname)
 		}
 		return nil, err
 	}
-	return &dto.Response{Data: user, Code: http.StatusOK}, nil
+	return user, nil
 }
-func NewUserService(log *logrus.Entry, db *db.Repository) UserService {
-	return &userServiceImpl{log: log, db: db}
+
+func (svc *userServiceImpl) SetUserAdmin(ctx context.Context, request *dto.SetUserAdminRequest) (*core.User, error) {
+	if err := svc.policy.Authorize(ctx, policy.ManageUsers, policy.Target{}); err != nil {
+		return nil, err
+	}
+
+	user, err := svc.db.UserRepository.SetUserAdmin(ctx, request.Nickname, request.Admin)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NotFound("user", request.Nickname, "Can't find user by nickname: %s", request.Nickname)
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func NewUserService(log *logrus.Entry, db *db.Repository, policy policy.Policy) UserService {
+	return &userServiceImpl{log: log, db: db, policy: policy}
 }
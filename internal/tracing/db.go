@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rinatkh/db_forum/internal/db"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RepositoryHook wraps every repository method call into a span.
+type RepositoryHook struct{}
+
+func (RepositoryHook) BeforeQuery(ctx context.Context, call *db.Call) context.Context {
+	ctx, _ = tracer().Start(ctx, call.Repository+"."+call.Method,
+		trace.WithTimestamp(call.Start),
+		trace.WithSpanKind(trace.SpanKindInternal),
+	)
+	return ctx
+}
+
+func (RepositoryHook) AfterQuery(ctx context.Context, call *db.Call) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int64("db.rows", call.Rows))
+	if call.Err != nil && !errors.Is(call.Err, pgx.ErrNoRows) {
+		span.RecordError(call.Err)
+		span.SetStatus(codes.Error, call.Err.Error())
+	}
+	span.End()
+}
+
+// QueryLogger is a pgx.Logger that turns every executed statement into a
+// client span under the span found in the query context. pgx reports a
+// statement once it is done, so the span start is derived from its duration.
+type QueryLogger struct{}
+
+func (QueryLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]any) {
+	if level > pgx.LogLevelInfo || !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		return
+	}
+	sql, ok := data["sql"].(string)
+	if !ok {
+		return
+	}
+
+	end := time.Now()
+	start := end
+	if elapsed, ok := data["time"].(time.Duration); ok {
+		start = end.Add(-elapsed)
+	}
+
+	_, span := tracer().Start(ctx, "db "+msg,
+		trace.WithTimestamp(start),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBStatementKey.String(sql),
+		),
+	)
+	if rows, ok := data["rowCount"].(int); ok {
+		span.SetAttributes(attribute.Int("db.rows", rows))
+	}
+	if tag, ok := data["commandTag"].(fmt.Stringer); ok {
+		span.SetAttributes(attribute.String("db.command_tag", tag.String()))
+	}
+	if err, ok := data["err"].(error); ok {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(end))
+}
@@ -0,0 +1,9 @@
+// Package forum embeds files of the repository root into the server.
+package forum
+
+import _ "embed"
+
+// Swagger is the OpenAPI 2.0 description of the HTTP API.
+//
+//go:embed swagger.yaml
+var Swagger []byte